@@ -1,13 +1,23 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
 	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jrudio/go-plex-client"
@@ -284,7 +294,7 @@ func getSessions(c *cli.Context) error {
 			userIsWatching += session.GrandparentTitle + " - " + session.ParentTitle
 			userIsWatching += " - " + session.Title
 		} else {
-			userIsWatching += session.Title + " (" + string(session.Year) + ")"
+			userIsWatching += session.Title + " (" + strconv.Itoa(session.Year) + ")"
 		}
 
 		fmt.Println(userIsWatching)
@@ -780,7 +790,7 @@ func stopPlayback(c *cli.Context) error {
 			title += session.GrandparentTitle + " - " + session.ParentTitle
 			title += " - " + session.Title
 		} else {
-			title += session.Title + " (" + string(session.Year) + ")"
+			title += session.Title + " (" + strconv.Itoa(session.Year) + ")"
 		}
 
 		fmt.Printf("\t[%d] %s - %s\n", i, session.User.Title, title)
@@ -886,6 +896,10 @@ func downloadMedia(c *cli.Context) error {
 		return err
 	}
 
+	if c.String("key") != "" || c.Int("playlist") != 0 {
+		return downloadRecursive(c, plexConn)
+	}
+
 	if c.NArg() == 0 {
 		return cli.NewExitError("search term is required", 1)
 	}
@@ -896,9 +910,11 @@ func downloadMedia(c *cli.Context) error {
 		downloadPath = "."
 	}
 
-	createFolders := c.Bool("folders")
+	opts, err := downloadOptions(c)
 
-	skipIfExists := c.Bool("skip")
+	if err != nil {
+		return err
+	}
 
 	// search for media
 	results, err := plexConn.Search(c.Args().First())
@@ -932,7 +948,15 @@ func downloadMedia(c *cli.Context) error {
 	selectedMedia := results.MediaContainer.Metadata[selection]
 
 	// download media
-	if err := plexConn.Download(selectedMedia, downloadPath, createFolders, skipIfExists); err != nil {
+	downloads, err := plexConn.DownloadAll([]plex.Metadata{selectedMedia}, downloadPath, opts)
+
+	for _, download := range downloads {
+		if download.Skipped {
+			fmt.Printf("skipped %s\n", download.Path)
+		}
+	}
+
+	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
 
@@ -941,6 +965,317 @@ func downloadMedia(c *cli.Context) error {
 	return nil
 }
 
+// downloadOptions reads the flags shared by every kind of download
+func downloadOptions(c *cli.Context) (plex.DownloadOptions, error) {
+	opts := plex.DownloadOptions{
+		CreateFolders: c.Bool("folders"),
+		SkipIfExists:  c.Bool("skip"),
+		Concurrency:   c.Int("concurrency"),
+		OnProgress:    printDownloadProgress,
+	}
+
+	if limit := c.String("limit"); limit != "" {
+		rate, err := parseByteRate(limit)
+
+		if err != nil {
+			return opts, cli.NewExitError(err, 1)
+		}
+
+		if opts.RateLimiter, err = plex.NewRateLimiter(rate); err != nil {
+			return opts, cli.NewExitError(err, 1)
+		}
+	}
+
+	if limit := c.String("per-file-limit"); limit != "" {
+		rate, err := parseByteRate(limit)
+
+		if err != nil {
+			return opts, cli.NewExitError(err, 1)
+		}
+
+		opts.PerFileRate = rate
+	}
+
+	if window := c.String("window"); window != "" {
+		timeWindow, err := plex.ParseTimeWindow(window)
+
+		if err != nil {
+			return opts, cli.NewExitError(err, 1)
+		}
+
+		opts.Window = &timeWindow
+	}
+
+	return opts, nil
+}
+
+// parseByteRate parses bytes per second with an optional K, M or G suffix, i.e. 500K or 2M
+func parseByteRate(rate string) (int64, error) {
+	multiplier := int64(1)
+	number := rate
+
+	switch strings.ToUpper(rate[len(rate)-1:]) {
+	case "K":
+		multiplier = 1 << 10
+	case "M":
+		multiplier = 1 << 20
+	case "G":
+		multiplier = 1 << 30
+	}
+
+	if multiplier != 1 {
+		number = rate[:len(rate)-1]
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+
+	// a fraction of a byte, i.e. 0.5, rounds down to no limit at all
+	bytesPerSecond := int64(value * float64(multiplier))
+
+	if err != nil || bytesPerSecond <= 0 {
+		return 0, fmt.Errorf("invalid rate %q, expected at least one byte per second i.e. 500K or 2M", rate)
+	}
+
+	return bytesPerSecond, nil
+}
+
+// transcodeMedia saves a transcoded copy of media or prints its url
+func transcodeMedia(c *cli.Context) error {
+	printURL := c.Bool("url")
+
+	if c.NArg() == 0 || (c.NArg() < 2 && !printURL) {
+		return cli.NewExitError("rating key and file are required", 1)
+	}
+
+	db, err := startDB()
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	defer db.Close()
+
+	plexConn, err := initPlex(db, true, true)
+
+	if err != nil {
+		return err
+	}
+
+	ratingKey := c.Args().First()
+
+	opts := plex.TranscodeOptions{
+		Protocol:        c.String("protocol"),
+		VideoResolution: c.String("resolution"),
+		MaxVideoBitrate: c.Int("bitrate"),
+		VideoCodec:      c.String("video-codec"),
+		AudioCodec:      c.String("audio-codec"),
+		BurnSubtitles:   c.Bool("burn-subtitles"),
+	}
+
+	if printURL {
+		transcodeURL, err := plexConn.TranscodeURL(ratingKey, opts)
+
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+
+		fmt.Println(transcodeURL)
+
+		return nil
+	}
+
+	path := c.Args().Get(1)
+
+	lastReport := time.Now()
+
+	opts.OnProgress = func(written int64) {
+		if time.Since(lastReport) < time.Second {
+			return
+		}
+
+		lastReport = time.Now()
+
+		fmt.Printf("\r%s: %.1f MB", path, float64(written)/(1<<20))
+	}
+
+	written, err := plexConn.DownloadTranscoded(ratingKey, path, opts)
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	fmt.Printf("\rsaved %s (%.1f MB)\n", path, float64(written)/(1<<20))
+
+	return nil
+}
+
+// syncLibrary mirrors a library section to a folder
+func syncLibrary(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return cli.NewExitError("section key and path are required", 1)
+	}
+
+	opts, err := downloadOptions(c)
+
+	if err != nil {
+		return err
+	}
+
+	opts.NameTemplate = c.String("template")
+
+	db, err := startDB()
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	defer db.Close()
+
+	plexConn, err := initPlex(db, true, true)
+
+	if err != nil {
+		return err
+	}
+
+	dryRun := c.Bool("dry-run")
+
+	report, err := plexConn.Sync(c.Args().First(), c.Args().Get(1), plex.SyncOptions{
+		Download:  opts,
+		StatePath: c.String("state"),
+		Prune:     c.Bool("prune"),
+		DryRun:    dryRun,
+	})
+
+	for _, change := range report.Changes {
+		line := fmt.Sprintf("%s %s", change.Action, change.Title)
+
+		if change.Reason != "" {
+			line += " (" + change.Reason + ")"
+		}
+
+		if change.Action == plex.SyncRemove && !c.Bool("prune") {
+			line += " (kept, use --prune to delete)"
+		}
+
+		fmt.Println(line)
+
+		for _, path := range change.Paths {
+			fmt.Printf("\t%s\n", path)
+		}
+	}
+
+	for _, download := range report.Downloads {
+		if download.Err != nil {
+			fmt.Printf("failed %s: %v\n", download.Item.Title, download.Err)
+		}
+	}
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	if dryRun {
+		fmt.Printf("dry run: %d changes, %d items up to date\n", len(report.Changes), report.Unchanged)
+		return nil
+	}
+
+	downloaded := 0
+
+	for _, download := range report.Downloads {
+		if download.Err == nil {
+			downloaded++
+		}
+	}
+
+	fmt.Printf("synced: %d files downloaded, %d deleted, %d items up to date\n", downloaded, len(report.Removed), report.Unchanged)
+
+	return nil
+}
+
+// downloadRecursive downloads every item of a show, season, artist, album, collection or playlist
+func downloadRecursive(c *cli.Context, plexConn *plex.Plex) error {
+	opts, err := downloadOptions(c)
+
+	if err != nil {
+		return err
+	}
+
+	var items []plex.Metadata
+
+	if playlistID := c.Int("playlist"); playlistID != 0 {
+		items, err = plexConn.ExpandPlaylist(playlistID)
+	} else {
+		items, err = plexConn.ExpandMedia(c.String("key"))
+	}
+
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to list media: %v", err), 1)
+	}
+
+	downloadPath := c.Args().First()
+
+	if downloadPath == "" {
+		downloadPath = "."
+	}
+
+	manifestPath := c.String("manifest")
+
+	if manifestPath != "" && !filepath.IsAbs(manifestPath) {
+		manifestPath = filepath.Join(downloadPath, manifestPath)
+	}
+
+	fmt.Printf("downloading %d items...\n", len(items))
+
+	opts.NameTemplate = c.String("template")
+	opts.ManifestPath = manifestPath
+
+	downloads, err := plexConn.DownloadAll(items, downloadPath, opts)
+
+	downloaded, skipped, failed := 0, 0, 0
+
+	for _, download := range downloads {
+		switch {
+		case download.Err != nil:
+			failed++
+			fmt.Printf("failed %s: %v\n", download.Item.Title, download.Err)
+		case download.Skipped:
+			skipped++
+			fmt.Printf("skipped %s\n", download.Path)
+		default:
+			downloaded++
+		}
+	}
+
+	if err != nil {
+		// i.e. the manifest could not be written
+		if failed == 0 {
+			return cli.NewExitError(err, 1)
+		}
+
+		return cli.NewExitError(fmt.Sprintf("%d of %d files failed", failed, len(downloads)), 1)
+	}
+
+	fmt.Printf("successfully downloaded %d files, skipped %d existing files\n", downloaded, skipped)
+
+	return nil
+}
+
+func printDownloadProgress(progress plex.DownloadProgress) {
+	name := filepath.Base(progress.Path)
+
+	if progress.Done {
+		fmt.Printf("%s: done\n", name)
+		return
+	}
+
+	if progress.Total > 0 {
+		fmt.Printf("%s: %.1f%%\n", name, float64(progress.Written)/float64(progress.Total)*100)
+		return
+	}
+
+	fmt.Printf("%s: %d bytes\n", name, progress.Written)
+}
+
 func getPlaylist(c *cli.Context) error {
 	db, err := startDB()
 
@@ -972,12 +1307,32 @@ func getPlaylist(c *cli.Context) error {
 		return cli.NewExitError(err, 1)
 	}
 
-	fmt.Println(result)
+	// the playlist item id is needed to remove or move an item
+	for _, item := range result.MediaContainer.Metadata {
+		fmt.Printf("\t[%d] %s\n", item.PlaylistItemID, item.Title)
+	}
 
 	return nil
 }
 
-func deleteMedia(c *cli.Context) error {
+// intArg parses the argument at index as an int
+func intArg(c *cli.Context, index int, name string) (int, error) {
+	arg := c.Args().Get(index)
+
+	if arg == "" {
+		return 0, fmt.Errorf("%s is required", name)
+	}
+
+	value, err := strconv.Atoi(arg)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %v", name, err)
+	}
+
+	return value, nil
+}
+
+func listPlaylists(c *cli.Context) error {
 	db, err := startDB()
 
 	if err != nil {
@@ -989,20 +1344,779 @@ func deleteMedia(c *cli.Context) error {
 	plexConn, err := initPlex(db, true, true)
 
 	if err != nil {
-		return err
+		return cli.NewExitError(err, 1)
 	}
 
-	if c.NArg() == 0 {
-		return cli.NewExitError("media id is required", 1)
+	playlists, err := plexConn.GetPlaylists(c.String("type"))
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
 	}
 
-	mediaID := c.Args().First()
+	if len(playlists) == 0 {
+		fmt.Println("no playlists found")
+		return nil
+	}
 
-	if err := plexConn.DeleteMediaByID(mediaID); err != nil {
+	for _, playlist := range playlists {
+		kind := playlist.PlaylistType
+
+		if playlist.Smart {
+			kind += ", smart"
+		}
+
+		fmt.Printf("\t[%s] %s (%s) - %d items\n", playlist.RatingKey, playlist.Title, kind, playlist.LeafCount)
+	}
+
+	return nil
+}
+
+func createPlaylist(c *cli.Context) error {
+	if c.NArg() < 2 {
+		return cli.NewExitError("a title and at least one media id are required", 1)
+	}
+
+	db, err := startDB()
+
+	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
 
-	fmt.Printf("successfully deleted media '%s'\n", mediaID)
+	defer db.Close()
+
+	plexConn, err := initPlex(db, true, true)
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	title := c.Args().First()
+
+	playlist, err := plexConn.CreatePlaylist(title, c.String("type"), c.Args().Tail())
+
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to create playlist: %v", err), 1)
+	}
+
+	fmt.Printf("created playlist '%s' with id %s\n", playlist.Title, playlist.RatingKey)
 
 	return nil
 }
+
+func createSmartPlaylist(c *cli.Context) error {
+	if c.NArg() < 2 {
+		return cli.NewExitError("a title and a section id are required", 1)
+	}
+
+	db, err := startDB()
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	defer db.Close()
+
+	plexConn, err := initPlex(db, true, true)
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	filter := c.String("filter")
+
+	if filter != "" && !strings.HasPrefix(filter, "?") {
+		filter = "?" + filter
+	}
+
+	playlist, err := plexConn.CreateSmartPlaylist(c.Args().Get(0), c.String("type"), c.Args().Get(1), filter)
+
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to create smart playlist: %v", err), 1)
+	}
+
+	fmt.Printf("created smart playlist '%s' with id %s\n", playlist.Title, playlist.RatingKey)
+
+	return nil
+}
+
+func addToPlaylist(c *cli.Context) error {
+	playlistID, err := intArg(c, 0, "playlist id")
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	if c.NArg() < 2 {
+		return cli.NewExitError("at least one media id is required", 1)
+	}
+
+	db, err := startDB()
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	defer db.Close()
+
+	plexConn, err := initPlex(db, true, true)
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	if err := plexConn.AddToPlaylist(playlistID, c.Args().Tail()); err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to add to playlist: %v", err), 1)
+	}
+
+	fmt.Println("success!")
+
+	return nil
+}
+
+func removeFromPlaylist(c *cli.Context) error {
+	playlistID, err := intArg(c, 0, "playlist id")
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	itemID, err := intArg(c, 1, "playlist item id")
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	db, err := startDB()
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	defer db.Close()
+
+	plexConn, err := initPlex(db, true, true)
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	if err := plexConn.RemoveFromPlaylist(playlistID, itemID); err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to remove from playlist: %v", err), 1)
+	}
+
+	fmt.Println("success!")
+
+	return nil
+}
+
+func movePlaylistItem(c *cli.Context) error {
+	playlistID, err := intArg(c, 0, "playlist id")
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	itemID, err := intArg(c, 1, "playlist item id")
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	// without a third argument the item is moved to the top
+	afterItemID := 0
+
+	if c.NArg() > 2 {
+		if afterItemID, err = intArg(c, 2, "playlist item id to move after"); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+	}
+
+	db, err := startDB()
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	defer db.Close()
+
+	plexConn, err := initPlex(db, true, true)
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	if err := plexConn.MovePlaylistItem(playlistID, itemID, afterItemID); err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to move playlist item: %v", err), 1)
+	}
+
+	fmt.Println("success!")
+
+	return nil
+}
+
+func renamePlaylist(c *cli.Context) error {
+	playlistID, err := intArg(c, 0, "playlist id")
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	title := strings.Join(c.Args().Tail(), " ")
+
+	if title == "" {
+		return cli.NewExitError("a title is required", 1)
+	}
+
+	db, err := startDB()
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	defer db.Close()
+
+	plexConn, err := initPlex(db, true, true)
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	if err := plexConn.RenamePlaylist(playlistID, title); err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to rename playlist: %v", err), 1)
+	}
+
+	fmt.Println("success!")
+
+	return nil
+}
+
+func deletePlaylist(c *cli.Context) error {
+	playlistID, err := intArg(c, 0, "playlist id")
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	db, err := startDB()
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	defer db.Close()
+
+	plexConn, err := initPlex(db, true, true)
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	if err := plexConn.DeletePlaylist(playlistID); err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to delete playlist: %v", err), 1)
+	}
+
+	fmt.Printf("successfully deleted playlist %d\n", playlistID)
+
+	return nil
+}
+
+func deleteMedia(c *cli.Context) error {
+	db, err := startDB()
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	defer db.Close()
+
+	plexConn, err := initPlex(db, true, true)
+
+	if err != nil {
+		return err
+	}
+
+	if c.NArg() == 0 {
+		return cli.NewExitError("media id is required", 1)
+	}
+
+	mediaID := c.Args().First()
+
+	if err := plexConn.DeleteMediaByID(mediaID); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	fmt.Printf("successfully deleted media '%s'\n", mediaID)
+
+	return nil
+}
+
+func listPlayers(c *cli.Context) error {
+	db, err := startDB()
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	defer db.Close()
+
+	plexConn, err := initPlex(db, true, true)
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	clients, err := plexConn.GetClients()
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	if len(clients) == 0 {
+		fmt.Println("no players connected to your server")
+		return nil
+	}
+
+	for _, client := range clients {
+		fmt.Printf("\t[%s] %s (%s)\n", client.MachineIdentifier, client.Name, client.Product)
+	}
+
+	return nil
+}
+
+// playerAction sets up a controller for the player id passed as first argument before running command
+func playerAction(command func(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if c.NArg() == 0 {
+			return cli.NewExitError("player id is required. use 'player list' to find it", 1)
+		}
+
+		db, err := startDB()
+
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+
+		defer db.Close()
+
+		plexConn, err := initPlex(db, true, true)
+
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+
+		player := plexConn.NewPlayerController(c.Args().First())
+
+		if mediaType := c.String("type"); mediaType != "" {
+			player.MediaType = mediaType
+		}
+
+		if err := command(plexConn, player, c); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+
+		return nil
+	}
+}
+
+func playerSeek(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+	seconds, err := intArg(c, 1, "offset in seconds")
+
+	if err != nil {
+		return err
+	}
+
+	return player.SeekTo(time.Duration(seconds) * time.Second)
+}
+
+func playerVolume(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+	volume, err := intArg(c, 1, "volume")
+
+	if err != nil {
+		return err
+	}
+
+	return player.SetVolume(volume)
+}
+
+func playerShuffle(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+	shuffle, err := strconv.ParseBool(c.Args().Get(1))
+
+	if err != nil {
+		return fmt.Errorf("shuffle must be true or false: %v", err)
+	}
+
+	return player.SetShuffle(shuffle)
+}
+
+func playerRepeat(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+	switch c.Args().Get(1) {
+	case "off":
+		return player.SetRepeat(plex.RepeatOff)
+	case "one":
+		return player.SetRepeat(plex.RepeatOne)
+	case "all":
+		return player.SetRepeat(plex.RepeatAll)
+	}
+
+	return errors.New("repeat mode must be off, one or all")
+}
+
+func playerStreams(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+	return player.SetStreams(c.String("audio"), c.String("subtitle"))
+}
+
+func playerNavigate(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+	return player.Navigate(c.Args().Get(1))
+}
+
+func playerPlayMedia(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+	ratingKey := c.Args().Get(1)
+
+	if ratingKey == "" {
+		return errors.New("media id is required")
+	}
+
+	mediaType := plex.PlaylistTypeVideo
+
+	if player.MediaType == plex.PlayerTypeMusic {
+		mediaType = plex.PlaylistTypeAudio
+	} else if player.MediaType == plex.PlayerTypePhoto {
+		mediaType = plex.PlaylistTypePhoto
+	}
+
+	queue, err := plexConn.CreatePlayQueue(mediaType, ratingKey, plex.PlayQueueOptions{
+		Shuffle:    c.Bool("shuffle"),
+		Continuous: true,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create play queue: %v", err)
+	}
+
+	return player.PlayMedia(queue, 0)
+}
+
+func playerTimeline(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+	timeline, err := player.PollTimeline()
+
+	if err != nil {
+		return err
+	}
+
+	active, ok := timeline.Active()
+
+	if !ok {
+		fmt.Println("nothing is playing")
+		return nil
+	}
+
+	fmt.Printf("%s %s (%s) %s / %s volume %d\n",
+		active.State,
+		active.Key,
+		active.Type,
+		time.Duration(active.Time)*time.Millisecond,
+		time.Duration(active.Duration)*time.Millisecond,
+		active.Volume,
+	)
+
+	return nil
+}
+
+// webhookRecord is a line of the log written by 'webhooks serve'
+type webhookRecord struct {
+	ReceivedAt       time.Time       `json:"receivedAt"`
+	Event            string          `json:"event"`
+	Payload          json.RawMessage `json:"payload"`
+	Thumb            []byte          `json:"thumb,omitempty"`
+	ThumbContentType string          `json:"thumbContentType,omitempty"`
+	ThumbFilename    string          `json:"thumbFilename,omitempty"`
+}
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func serveWebhooks(c *cli.Context) error {
+	logFile, err := os.OpenFile(c.String("log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to open log: %v", err), 1)
+	}
+
+	defer logFile.Close()
+
+	wh := plex.NewWebhook()
+
+	wh.OnError(func(err error) {
+		fmt.Println(err)
+	})
+
+	wh.OnAny(func(w plex.Webhook) {
+		fmt.Printf("%s: %s (%s on %s)\n", w.Event, w.Metadata.Title, w.Account.Title, w.Player.Title)
+	})
+
+	if secret := c.String("secret"); secret != "" && c.Bool("secret-in-path") {
+		wh.SetPathSecret(secret)
+	} else if secret != "" {
+		wh.SetSecret(secret)
+	}
+
+	if sources := c.StringSlice("allow"); len(sources) > 0 {
+		if err := wh.AllowSources(sources...); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+	}
+
+	if servers := c.StringSlice("server"); len(servers) > 0 {
+		wh.AllowServers(servers...)
+	}
+
+	if dir := c.String("thumbs"); dir != "" {
+		wh.SetThumbnailSink(plex.DirThumbnailSink{Dir: dir})
+	}
+
+	var mu sync.Mutex
+
+	encoder := json.NewEncoder(logFile)
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+
+		// the handler checks and size limits the request before reading it, so only the form of
+		// an accepted webhook is logged, with its exact payload
+		wh.Handler(recorder, r)
+
+		if recorder.statusCode != http.StatusOK {
+			return
+		}
+
+		record := newWebhookRecord(r)
+
+		if record == nil {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err := encoder.Encode(record); err != nil {
+			fmt.Printf("failed to log webhook: %v\n", err)
+		}
+	}
+
+	server := &http.Server{
+		Addr:    c.String("addr"),
+		Handler: http.HandlerFunc(handler),
+	}
+
+	interrupt := make(chan os.Signal, 1)
+
+	signal.Notify(interrupt, os.Interrupt)
+
+	go func() {
+		<-interrupt
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		server.Shutdown(ctx)
+	}()
+
+	fmt.Printf("listening for webhooks on %s, logging to %s\n", server.Addr, logFile.Name())
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return cli.NewExitError(err, 1)
+	}
+
+	return nil
+}
+
+// newWebhookRecord returns the record of a webhook request parsed by the handler or nil if it has no
+// payload. Thumbnails are held in memory as they are below the memory limit of the form
+func newWebhookRecord(r *http.Request) *webhookRecord {
+	if r.MultipartForm == nil || len(r.MultipartForm.Value["payload"]) == 0 {
+		return nil
+	}
+
+	payload := []byte(r.MultipartForm.Value["payload"][0])
+
+	var event struct {
+		Event string `json:"event"`
+	}
+
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil
+	}
+
+	record := &webhookRecord{
+		ReceivedAt: time.Now(),
+		Event:      event.Event,
+		Payload:    payload,
+	}
+
+	if files := r.MultipartForm.File["thumb"]; len(files) > 0 {
+		file, err := files[0].Open()
+
+		if err != nil {
+			return record
+		}
+
+		defer file.Close()
+
+		if thumb, err := ioutil.ReadAll(file); err == nil {
+			record.Thumb = thumb
+			record.ThumbContentType = files[0].Header.Get("Content-Type")
+			record.ThumbFilename = files[0].Filename
+		}
+	}
+
+	return record
+}
+
+func replayWebhooks(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return cli.NewExitError("a webhook log file is required", 1)
+	}
+
+	target := c.String("to")
+
+	if target == "" {
+		return cli.NewExitError("--to is required", 1)
+	}
+
+	logFile, err := os.Open(c.Args().First())
+
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	defer logFile.Close()
+
+	event := c.String("event")
+	realtime := c.Bool("realtime")
+
+	client := http.Client{Timeout: 30 * time.Second}
+
+	scanner := bufio.NewScanner(logFile)
+
+	// lines carry base64 encoded thumbnails
+	scanner.Buffer(make([]byte, 64*1024), 32<<20)
+
+	var (
+		previous time.Time
+		failed   int
+	)
+
+	for scanner.Scan() {
+		var record webhookRecord
+
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return cli.NewExitError(fmt.Sprintf("invalid webhook log: %v", err), 1)
+		}
+
+		if event != "" && record.Event != event {
+			continue
+		}
+
+		if realtime && !previous.IsZero() {
+			time.Sleep(record.ReceivedAt.Sub(previous))
+		}
+
+		previous = record.ReceivedAt
+
+		statusCode, err := postWebhook(client, target, record)
+
+		if err != nil {
+			failed++
+			fmt.Printf("%s: %v\n", record.Event, err)
+			continue
+		}
+
+		if statusCode != http.StatusOK {
+			failed++
+		}
+
+		fmt.Printf("%s: %d\n", record.Event, statusCode)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	if failed > 0 {
+		return cli.NewExitError(fmt.Sprintf("%d webhooks failed", failed), 1)
+	}
+
+	return nil
+}
+
+// postWebhook sends a recorded webhook the way Plex does: a multipart form with a payload
+// field and an optional thumb file
+func postWebhook(client http.Client, target string, record webhookRecord) (int, error) {
+	var body bytes.Buffer
+
+	form := multipart.NewWriter(&body)
+
+	if err := form.WriteField("payload", string(record.Payload)); err != nil {
+		return 0, err
+	}
+
+	if len(record.Thumb) > 0 {
+		filename := record.ThumbFilename
+
+		if filename == "" {
+			filename = "thumb.jpg"
+		}
+
+		contentType := record.ThumbContentType
+
+		if contentType == "" {
+			contentType = "image/jpeg"
+		}
+
+		header := textproto.MIMEHeader{}
+
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="thumb"; filename="%s"`, filename))
+		header.Set("Content-Type", contentType)
+
+		part, err := form.CreatePart(header)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err := part.Write(record.Thumb); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := form.Close(); err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target, &body)
+
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("User-Agent", "PlexMediaServer")
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return 0, err
+	}
+
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
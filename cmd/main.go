@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/jrudio/go-plex-client"
 	"github.com/urfave/cli"
 )
 
@@ -14,6 +15,15 @@ const (
 
 var (
 	isVerbose bool
+
+	// playerFlags are shared by the player subcommands
+	playerFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "type",
+			Value: plex.PlayerTypeVideo,
+			Usage: "media `type` the command applies to: video, music or photo",
+		},
+	}
 )
 
 type server struct {
@@ -113,6 +123,65 @@ func main() {
 					Usage: "delete a webhook",
 				},
 			},
+			Subcommands: []cli.Command{
+				{
+					Name:   "serve",
+					Usage:  "receive webhooks on a local port and log them as json lines",
+					Action: serveWebhooks,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "addr",
+							Value: ":8080",
+							Usage: "`address` to listen on",
+						},
+						cli.StringFlag{
+							Name:  "log",
+							Value: "webhooks.jsonl",
+							Usage: "`file` webhooks are appended to",
+						},
+						cli.StringFlag{
+							Name:  "secret",
+							Usage: "only accept webhooks with this `secret` as the secret query parameter",
+						},
+						cli.BoolFlag{
+							Name:  "secret-in-path",
+							Usage: "expect the secret as the last segment of the url path instead, i.e. /webhook/{secret}",
+						},
+						cli.StringSliceFlag{
+							Name:  "allow",
+							Usage: "only accept webhooks from this `cidr`. can be repeated",
+						},
+						cli.StringSliceFlag{
+							Name:  "server",
+							Usage: "only accept webhooks from the server with this machine `id`. can be repeated",
+						},
+						cli.StringFlag{
+							Name:  "thumbs",
+							Usage: "save thumbnails to `dir`",
+						},
+					},
+				},
+				{
+					Name:      "replay",
+					Usage:     "post webhooks logged by 'webhooks serve' to a url",
+					ArgsUsage: "<file>",
+					Action:    replayWebhooks,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "to",
+							Usage: "`url` the webhooks are posted to",
+						},
+						cli.StringFlag{
+							Name:  "event",
+							Usage: "only replay webhooks of `event`, i.e. media.play",
+						},
+						cli.BoolFlag{
+							Name:  "realtime",
+							Usage: "keep the time between webhooks as they were received",
+						},
+					},
+				},
+			},
 		},
 		{
 			Name:   "search",
@@ -150,9 +219,10 @@ func main() {
 			Action: getMetadata,
 		},
 		{
-			Name:   "download",
-			Usage:  "download media from your plex server",
-			Action: downloadMedia,
+			Name:      "download",
+			Usage:     "download media from your plex server",
+			ArgsUsage: "<search term> | --key <rating key> [path]",
+			Action:    downloadMedia,
 			Flags: []cli.Flag{
 				cli.BoolFlag{
 					Name:  "folders",
@@ -162,12 +232,343 @@ func main() {
 					Name:  "skip",
 					Usage: "skip download if file already exists",
 				},
+				cli.IntFlag{
+					Name:  "concurrency",
+					Value: 2,
+					Usage: "number of files downloaded at once",
+				},
+				cli.StringFlag{
+					Name:  "key",
+					Usage: "download every item of the show, season, artist, album or collection with rating `key` instead of searching",
+				},
+				cli.IntFlag{
+					Name:  "playlist",
+					Usage: "download every item of the playlist with `id` instead of searching",
+				},
+				cli.StringFlag{
+					Name:  "template",
+					Usage: "name files with `template`, i.e. \"" + plex.NameTemplateShow + "\"",
+				},
+				cli.StringFlag{
+					Name:  "manifest",
+					Value: "manifest.json",
+					Usage: "`file` listing the downloaded files when using --key or --playlist. relative to the download path",
+				},
+				cli.StringFlag{
+					Name:  "limit",
+					Usage: "limit the combined download speed to `rate` bytes per second, i.e. 500K or 2M",
+				},
+				cli.StringFlag{
+					Name:  "per-file-limit",
+					Usage: "limit the speed of each file to `rate` bytes per second, i.e. 500K or 2M",
+				},
+				cli.StringFlag{
+					Name:  "window",
+					Usage: "only download during the daily `window` of local time, i.e. 01:00-07:00",
+				},
+			},
+		},
+		{
+			Name:      "transcode",
+			Usage:     "download a transcoded copy of media, i.e. a mobile sized one",
+			ArgsUsage: "<rating key> <file>",
+			Action:    transcodeMedia,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "protocol",
+					Value: plex.TranscodeProtocolHTTP,
+					Usage: "transcode `protocol`: http for a single mp4 file, hls for joined MPEG-TS segments or dash with --url",
+				},
+				cli.StringFlag{
+					Name:  "resolution",
+					Usage: "maximum video `resolution`, i.e. 1280x720",
+				},
+				cli.IntFlag{
+					Name:  "bitrate",
+					Usage: "maximum video bitrate in `kbps`, i.e. 2000",
+				},
+				cli.StringFlag{
+					Name:  "video-codec",
+					Usage: "video `codec`, defaults to h264",
+				},
+				cli.StringFlag{
+					Name:  "audio-codec",
+					Usage: "audio `codec`, defaults to aac",
+				},
+				cli.BoolFlag{
+					Name:  "burn-subtitles",
+					Usage: "burn the selected subtitles into the video",
+				},
+				cli.BoolFlag{
+					Name:  "url",
+					Usage: "print the transcode url instead of downloading it",
+				},
+			},
+		},
+		{
+			Name:      "sync",
+			Usage:     "keep an offline copy of a library section, downloading new and changed media",
+			ArgsUsage: "<section key> <path>",
+			Action:    syncLibrary,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "only print what would be downloaded or deleted",
+				},
+				cli.BoolFlag{
+					Name:  "prune",
+					Usage: "delete media that was removed from the library",
+				},
+				cli.StringFlag{
+					Name:  "state",
+					Usage: "`file` recording what was synced. defaults to .plexsync.json in the path",
+				},
+				cli.BoolFlag{
+					Name:  "folders",
+					Usage: "create folder hierarchy",
+				},
+				cli.IntFlag{
+					Name:  "concurrency",
+					Value: 2,
+					Usage: "number of files downloaded at once",
+				},
+				cli.StringFlag{
+					Name:  "template",
+					Usage: "name files with `template`, i.e. \"" + plex.NameTemplateShow + "\"",
+				},
+				cli.StringFlag{
+					Name:  "limit",
+					Usage: "limit the combined download speed to `rate` bytes per second, i.e. 500K or 2M",
+				},
+				cli.StringFlag{
+					Name:  "per-file-limit",
+					Usage: "limit the speed of each file to `rate` bytes per second, i.e. 500K or 2M",
+				},
+				cli.StringFlag{
+					Name:  "window",
+					Usage: "only download during the daily `window` of local time, i.e. 01:00-07:00",
+				},
 			},
 		},
 		{
 			Name:   "playlist",
-			Usage:  "print playlsit items on plex server",
+			Usage:  "print playlist items on plex server or manage playlists",
 			Action: getPlaylist,
+			Subcommands: []cli.Command{
+				{
+					Name:   "list",
+					Usage:  "list playlists on your plex server",
+					Action: listPlaylists,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "type",
+							Usage: "only list playlists of `type` audio, video or photo",
+						},
+					},
+				},
+				{
+					Name:      "create",
+					Usage:     "create a playlist from media ids",
+					ArgsUsage: "<title> <media id>...",
+					Action:    createPlaylist,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "type",
+							Value: plex.PlaylistTypeVideo,
+							Usage: "playlist `type`: audio, video or photo",
+						},
+					},
+				},
+				{
+					Name:      "smart",
+					Usage:     "create a smart playlist from a library section filter",
+					ArgsUsage: "<title> <section id>",
+					Action:    createSmartPlaylist,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "type",
+							Value: plex.PlaylistTypeVideo,
+							Usage: "playlist `type`: audio, video or photo",
+						},
+						cli.StringFlag{
+							Name:  "filter",
+							Usage: "library `filter`, i.e. type=1&unwatched=1&sort=addedAt:desc",
+						},
+					},
+				},
+				{
+					Name:      "add",
+					Usage:     "add media to a playlist",
+					ArgsUsage: "<playlist id> <media id>...",
+					Action:    addToPlaylist,
+				},
+				{
+					Name:      "remove",
+					Usage:     "remove an item from a playlist",
+					ArgsUsage: "<playlist id> <playlist item id>",
+					Action:    removeFromPlaylist,
+				},
+				{
+					Name:      "move",
+					Usage:     "move an item after another item or to the top of a playlist",
+					ArgsUsage: "<playlist id> <playlist item id> [after playlist item id]",
+					Action:    movePlaylistItem,
+				},
+				{
+					Name:      "rename",
+					Usage:     "rename a playlist",
+					ArgsUsage: "<playlist id> <title>",
+					Action:    renamePlaylist,
+				},
+				{
+					Name:      "delete",
+					Usage:     "delete a playlist",
+					ArgsUsage: "<playlist id>",
+					Action:    deletePlaylist,
+				},
+			},
+		},
+		{
+			Name:  "player",
+			Usage: "remote control a player connected to your plex server",
+			Subcommands: []cli.Command{
+				{
+					Name:   "list",
+					Usage:  "list players connected to your plex server",
+					Action: listPlayers,
+				},
+				{
+					Name:      "play",
+					Usage:     "resume playback",
+					ArgsUsage: "<player id>",
+					Flags:     playerFlags,
+					Action: playerAction(func(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+						return player.Play()
+					}),
+				},
+				{
+					Name:      "pause",
+					Usage:     "pause playback",
+					ArgsUsage: "<player id>",
+					Flags:     playerFlags,
+					Action: playerAction(func(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+						return player.Pause()
+					}),
+				},
+				{
+					Name:      "stop",
+					Usage:     "stop playback",
+					ArgsUsage: "<player id>",
+					Flags:     playerFlags,
+					Action: playerAction(func(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+						return player.Stop()
+					}),
+				},
+				{
+					Name:      "next",
+					Usage:     "skip to the next item",
+					ArgsUsage: "<player id>",
+					Flags:     playerFlags,
+					Action: playerAction(func(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+						return player.SkipNext()
+					}),
+				},
+				{
+					Name:      "previous",
+					Usage:     "skip to the previous item",
+					ArgsUsage: "<player id>",
+					Flags:     playerFlags,
+					Action: playerAction(func(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+						return player.SkipPrevious()
+					}),
+				},
+				{
+					Name:      "forward",
+					Usage:     "step forward a few seconds",
+					ArgsUsage: "<player id>",
+					Flags:     playerFlags,
+					Action: playerAction(func(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+						return player.StepForward()
+					}),
+				},
+				{
+					Name:      "back",
+					Usage:     "step back a few seconds",
+					ArgsUsage: "<player id>",
+					Flags:     playerFlags,
+					Action: playerAction(func(plexConn *plex.Plex, player *plex.PlayerController, c *cli.Context) error {
+						return player.StepBack()
+					}),
+				},
+				{
+					Name:      "seek",
+					Usage:     "seek to an offset in seconds",
+					ArgsUsage: "<player id> <seconds>",
+					Flags:     playerFlags,
+					Action:    playerAction(playerSeek),
+				},
+				{
+					Name:      "volume",
+					Usage:     "set the volume from 0 to 100",
+					ArgsUsage: "<player id> <volume>",
+					Flags:     playerFlags,
+					Action:    playerAction(playerVolume),
+				},
+				{
+					Name:      "shuffle",
+					Usage:     "turn shuffle on or off",
+					ArgsUsage: "<player id> <true|false>",
+					Flags:     playerFlags,
+					Action:    playerAction(playerShuffle),
+				},
+				{
+					Name:      "repeat",
+					Usage:     "set the repeat mode",
+					ArgsUsage: "<player id> <off|one|all>",
+					Flags:     playerFlags,
+					Action:    playerAction(playerRepeat),
+				},
+				{
+					Name:      "streams",
+					Usage:     "select the audio and subtitle streams",
+					ArgsUsage: "<player id>",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "audio",
+							Usage: "audio stream `id`",
+						},
+						cli.StringFlag{
+							Name:  "subtitle",
+							Usage: "subtitle stream `id`, 0 turns subtitles off",
+						},
+					}, playerFlags...),
+					Action: playerAction(playerStreams),
+				},
+				{
+					Name:      "nav",
+					Usage:     "send a navigation key: moveUp, moveDown, moveLeft, moveRight, pageUp, pageDown, select, back, home, contextMenu or toggleOSD",
+					ArgsUsage: "<player id> <key>",
+					Action:    playerAction(playerNavigate),
+				},
+				{
+					Name:      "play-media",
+					Usage:     "play media from your plex server on the player",
+					ArgsUsage: "<player id> <media id>",
+					Flags: append([]cli.Flag{
+						cli.BoolFlag{
+							Name:  "shuffle",
+							Usage: "shuffle the play queue",
+						},
+					}, playerFlags...),
+					Action: playerAction(playerPlayMedia),
+				},
+				{
+					Name:      "timeline",
+					Usage:     "display what the player is playing",
+					ArgsUsage: "<player id>",
+					Action:    playerAction(playerTimeline),
+				},
+			},
 		},
 		{
 			Name:  "delete",
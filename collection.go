@@ -0,0 +1,314 @@
+package plex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Collection modes control how a collection is displayed in its library
+const (
+	CollectionModeDefault   = -1 // use the library's setting
+	CollectionModeHide      = 0  // hide the collection
+	CollectionModeHideItems = 1  // show the collection in place of its items
+	CollectionModeShowItems = 2  // show both the collection and its items
+)
+
+// Collection sort orders of the items in a collection
+const (
+	CollectionSortRelease = 0
+	CollectionSortAlpha   = 1
+	CollectionSortCustom  = 2
+)
+
+// collectionMediaTypeID is the plex media type of a collection
+const collectionMediaTypeID = "18"
+
+// Collection is a regular or smart collection of a library section
+type Collection struct {
+	AddedAt             int         `json:"addedAt"`
+	Art                 string      `json:"art"`
+	ChildCount          json.Number `json:"childCount"`
+	CollectionMode      json.Number `json:"collectionMode"`
+	CollectionSort      json.Number `json:"collectionSort"`
+	ContentRating       string      `json:"contentRating"`
+	GUID                string      `json:"guid"`
+	Index               int         `json:"index"`
+	Key                 string      `json:"key"`
+	LibrarySectionID    json.Number `json:"librarySectionID"`
+	LibrarySectionTitle string      `json:"librarySectionTitle"`
+	MaxYear             json.Number `json:"maxYear"`
+	MinYear             json.Number `json:"minYear"`
+	RatingKey           string      `json:"ratingKey"`
+	Smart               boolOrInt   `json:"smart"`
+	Subtype             string      `json:"subtype"`
+	Summary             string      `json:"summary"`
+	Thumb               string      `json:"thumb"`
+	Title               string      `json:"title"`
+	TitleSort           string      `json:"titleSort"`
+	Type                string      `json:"type"`
+	UpdatedAt           int         `json:"updatedAt"`
+}
+
+// Count returns the number of items in the collection
+func (c Collection) Count() int {
+	count, _ := strconv.Atoi(c.ChildCount.String())
+
+	return count
+}
+
+// CollectionContainer is the response of the collection endpoints
+type CollectionContainer struct {
+	MediaContainer struct {
+		Metadata []Collection `json:"Metadata"`
+		Size     int          `json:"size"`
+	} `json:"MediaContainer"`
+}
+
+// GetCollections lists the collections of a library section
+func (p *Plex) GetCollections(sectionKey string) ([]Collection, error) {
+	return p.GetCollectionsContext(context.Background(), sectionKey)
+}
+
+// GetCollectionsContext is like GetCollections but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetCollectionsContext(ctx context.Context, sectionKey string) ([]Collection, error) {
+	query := fmt.Sprintf("%s/library/sections/%s/collections", p.URL, sectionKey)
+
+	var result CollectionContainer
+
+	if err := p.requestJSON(ctx, http.MethodGet, query, &result); err != nil {
+		return []Collection{}, err
+	}
+
+	return result.MediaContainer.Metadata, nil
+}
+
+// GetCollectionItems returns the media inside a collection
+func (p *Plex) GetCollectionItems(collectionID string) (SearchResults, error) {
+	return p.GetCollectionItemsContext(context.Background(), collectionID)
+}
+
+// GetCollectionItemsContext is like GetCollectionItems but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetCollectionItemsContext(ctx context.Context, collectionID string) (SearchResults, error) {
+	query := fmt.Sprintf("%s/library/collections/%s/children", p.URL, collectionID)
+
+	var results SearchResults
+
+	if err := p.requestJSON(ctx, http.MethodGet, query, &results); err != nil {
+		return SearchResults{}, err
+	}
+
+	return results, nil
+}
+
+// CreateCollection creates a collection in a library section containing the media of ratingKeys.
+// mediaType is the type of the media (i.e. movie, show, artist). See GetMediaTypeID
+func (p *Plex) CreateCollection(sectionKey, title, mediaType string, ratingKeys []string) (Collection, error) {
+	return p.CreateCollectionContext(context.Background(), sectionKey, title, mediaType, ratingKeys)
+}
+
+// CreateCollectionContext is like CreateCollection but uses ctx to cancel the requests or bound their duration
+func (p *Plex) CreateCollectionContext(ctx context.Context, sectionKey, title, mediaType string, ratingKeys []string) (Collection, error) {
+	if len(ratingKeys) == 0 {
+		return Collection{}, errors.New("at least one rating key is required")
+	}
+
+	uri, err := p.libraryURI(ctx, "/library/metadata/"+strings.Join(ratingKeys, ","))
+
+	if err != nil {
+		return Collection{}, err
+	}
+
+	return p.createCollection(ctx, sectionKey, title, mediaType, uri, false)
+}
+
+// CreateSmartCollection creates a collection that is kept up to date with the content of
+// a library section matching filter. filter is a query suffix such as the one rendered by Filter.String
+func (p *Plex) CreateSmartCollection(sectionKey, title, mediaType, filter string) (Collection, error) {
+	return p.CreateSmartCollectionContext(context.Background(), sectionKey, title, mediaType, filter)
+}
+
+// CreateSmartCollectionContext is like CreateSmartCollection but uses ctx to cancel the requests or bound their duration
+func (p *Plex) CreateSmartCollectionContext(ctx context.Context, sectionKey, title, mediaType, filter string) (Collection, error) {
+	uri, err := p.libraryURI(ctx, fmt.Sprintf("/library/sections/%s/all%s", sectionKey, filter))
+
+	if err != nil {
+		return Collection{}, err
+	}
+
+	return p.createCollection(ctx, sectionKey, title, mediaType, uri, true)
+}
+
+func (p *Plex) createCollection(ctx context.Context, sectionKey, title, mediaType, uri string, smart bool) (Collection, error) {
+	if title == "" {
+		return Collection{}, errors.New("a title is required")
+	}
+
+	params := url.Values{}
+
+	params.Set("type", GetMediaTypeID(mediaType))
+	params.Set("title", title)
+	params.Set("smart", boolToOneOrZero(smart))
+	params.Set("sectionId", sectionKey)
+	params.Set("uri", uri)
+
+	var result CollectionContainer
+
+	if err := p.requestJSON(ctx, http.MethodPost, p.URL+"/library/collections?"+params.Encode(), &result); err != nil {
+		return Collection{}, err
+	}
+
+	if len(result.MediaContainer.Metadata) == 0 {
+		return Collection{}, errors.New("plex did not return the new collection")
+	}
+
+	return result.MediaContainer.Metadata[0], nil
+}
+
+// AddToCollection adds the media of ratingKeys to a collection
+func (p *Plex) AddToCollection(collectionID string, ratingKeys []string) error {
+	return p.AddToCollectionContext(context.Background(), collectionID, ratingKeys)
+}
+
+// AddToCollectionContext is like AddToCollection but uses ctx to cancel the requests or bound their duration
+func (p *Plex) AddToCollectionContext(ctx context.Context, collectionID string, ratingKeys []string) error {
+	if len(ratingKeys) == 0 {
+		return errors.New("at least one rating key is required")
+	}
+
+	uri, err := p.libraryURI(ctx, "/library/metadata/"+strings.Join(ratingKeys, ","))
+
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("%s/library/collections/%s/items?uri=%s", p.URL, collectionID, url.QueryEscape(uri))
+
+	return p.requestJSON(ctx, http.MethodPut, query, nil)
+}
+
+// RemoveFromCollection removes the media ratingKey from a collection
+func (p *Plex) RemoveFromCollection(collectionID, ratingKey string) error {
+	return p.RemoveFromCollectionContext(context.Background(), collectionID, ratingKey)
+}
+
+// RemoveFromCollectionContext is like RemoveFromCollection but uses ctx to cancel the request or bound its duration
+func (p *Plex) RemoveFromCollectionContext(ctx context.Context, collectionID, ratingKey string) error {
+	query := fmt.Sprintf("%s/library/collections/%s/items/%s", p.URL, collectionID, ratingKey)
+
+	return p.requestJSON(ctx, http.MethodDelete, query, nil)
+}
+
+// SetCollectionMode changes how a collection is displayed in its library. See CollectionModeDefault
+func (p *Plex) SetCollectionMode(collectionID string, mode int) error {
+	return p.SetCollectionModeContext(context.Background(), collectionID, mode)
+}
+
+// SetCollectionModeContext is like SetCollectionMode but uses ctx to cancel the request or bound its duration
+func (p *Plex) SetCollectionModeContext(ctx context.Context, collectionID string, mode int) error {
+	if mode < CollectionModeDefault || mode > CollectionModeShowItems {
+		return fmt.Errorf("invalid collection mode %d", mode)
+	}
+
+	return p.setCollectionPref(ctx, collectionID, "collectionMode", mode)
+}
+
+// SetCollectionSort changes the order of the items in a collection. See CollectionSortRelease
+func (p *Plex) SetCollectionSort(collectionID string, sort int) error {
+	return p.SetCollectionSortContext(context.Background(), collectionID, sort)
+}
+
+// SetCollectionSortContext is like SetCollectionSort but uses ctx to cancel the request or bound its duration
+func (p *Plex) SetCollectionSortContext(ctx context.Context, collectionID string, sort int) error {
+	if sort < CollectionSortRelease || sort > CollectionSortCustom {
+		return fmt.Errorf("invalid collection sort %d", sort)
+	}
+
+	return p.setCollectionPref(ctx, collectionID, "collectionSort", sort)
+}
+
+func (p *Plex) setCollectionPref(ctx context.Context, collectionID, pref string, value int) error {
+	query := fmt.Sprintf("%s/library/metadata/%s/prefs?%s=%d", p.URL, collectionID, pref, value)
+
+	return p.requestJSON(ctx, http.MethodPut, query, nil)
+}
+
+// EditCollectionSummary changes the summary of a collection and locks it so agents do not overwrite it
+func (p *Plex) EditCollectionSummary(sectionKey, collectionID, summary string) error {
+	return p.EditCollectionSummaryContext(context.Background(), sectionKey, collectionID, summary)
+}
+
+// EditCollectionSummaryContext is like EditCollectionSummary but uses ctx to cancel the request or bound its duration
+func (p *Plex) EditCollectionSummaryContext(ctx context.Context, sectionKey, collectionID, summary string) error {
+	vals := url.Values{}
+
+	vals.Add("type", collectionMediaTypeID)
+	vals.Add("id", collectionID)
+	vals.Add("summary.value", summary)
+	vals.Add("summary.locked", "1")
+
+	query := fmt.Sprintf("%s/library/sections/%s/all?%s", p.URL, sectionKey, vals.Encode())
+
+	return p.requestJSON(ctx, http.MethodPut, query, nil)
+}
+
+// SetCollectionPoster sets the poster of a collection to the image at posterURL
+func (p *Plex) SetCollectionPoster(collectionID, posterURL string) error {
+	return p.SetCollectionPosterContext(context.Background(), collectionID, posterURL)
+}
+
+// SetCollectionPosterContext is like SetCollectionPoster but uses ctx to cancel the request or bound its duration
+func (p *Plex) SetCollectionPosterContext(ctx context.Context, collectionID, posterURL string) error {
+	query := fmt.Sprintf("%s/library/metadata/%s/posters?url=%s", p.URL, collectionID, url.QueryEscape(posterURL))
+
+	return p.requestJSON(ctx, http.MethodPost, query, nil)
+}
+
+// UploadCollectionPoster uploads image as the poster of a collection
+func (p *Plex) UploadCollectionPoster(collectionID string, image []byte) error {
+	return p.UploadCollectionPosterContext(context.Background(), collectionID, image)
+}
+
+// UploadCollectionPosterContext is like UploadCollectionPoster but uses ctx to cancel the request or bound its duration
+func (p *Plex) UploadCollectionPosterContext(ctx context.Context, collectionID string, image []byte) error {
+	if len(image) == 0 {
+		return errors.New("image is empty")
+	}
+
+	query := fmt.Sprintf("%s/library/metadata/%s/posters", p.URL, collectionID)
+
+	h := p.Headers
+
+	h.ContentType = http.DetectContentType(image)
+
+	resp, err := p.post(ctx, query, image, h)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp)
+	}
+
+	return nil
+}
+
+// DeleteCollection deletes a collection. The media in it is left untouched
+func (p *Plex) DeleteCollection(collectionID string) error {
+	return p.DeleteCollectionContext(context.Background(), collectionID)
+}
+
+// DeleteCollectionContext is like DeleteCollection but uses ctx to cancel the request or bound its duration
+func (p *Plex) DeleteCollectionContext(ctx context.Context, collectionID string) error {
+	query := fmt.Sprintf("%s/library/collections/%s", p.URL, collectionID)
+
+	return p.requestJSON(ctx, http.MethodDelete, query, nil)
+}
@@ -0,0 +1,155 @@
+package plex
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newCollectionServer(t *testing.T, requests *[]string) (*httptest.Server, *Plex) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+
+		w.Header().Set("Content-Type", applicationJson)
+
+		switch {
+		case r.URL.Path == "/identity":
+			fmt.Fprint(w, `{"MediaContainer":{"size":0,"machineIdentifier":"abc123"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/library/sections/1/collections":
+			fmt.Fprint(w, testCollections)
+		case r.Method == http.MethodPost && r.URL.Path == "/library/collections":
+			query := r.URL.Query()
+
+			if query.Get("type") != "1" || query.Get("sectionId") != "1" || query.Get("title") != "Heist Movies" {
+				t.Errorf("unexpected collection params: %s", r.URL.RawQuery)
+			}
+
+			if uri := "server://abc123/com.plexapp.plugins.library/library/metadata/10,11"; query.Get("uri") != uri {
+				t.Errorf("expected uri %s, got %s", uri, query.Get("uri"))
+			}
+
+			fmt.Fprint(w, testCreatedCollection)
+		case r.URL.Path == "/library/metadata/5003/prefs":
+			if r.URL.Query().Get("collectionMode") != "1" {
+				t.Errorf("expected collection mode 1, got %s", r.URL.RawQuery)
+			}
+		case r.URL.Path == "/library/sections/1/all":
+			query := r.URL.Query()
+
+			if query.Get("type") != "18" || query.Get("id") != "5003" || query.Get("summary.value") != "crews and vaults" {
+				t.Errorf("unexpected edit params: %s", r.URL.RawQuery)
+			}
+		case r.URL.Path == "/library/metadata/5003/posters":
+			body, _ := ioutil.ReadAll(r.Body)
+
+			if r.Header.Get("Content-Type") != "image/png" || len(body) == 0 {
+				t.Errorf("expected a png upload, got %s with %d bytes", r.Header.Get("Content-Type"), len(body))
+			}
+		case r.URL.Path == "/library/collections/5003/items", r.URL.Path == "/library/collections/5003/items/10", r.URL.Path == "/library/collections/5003":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return server, p
+}
+
+func TestGetCollections(t *testing.T) {
+	var requests []string
+
+	server, p := newCollectionServer(t, &requests)
+	defer server.Close()
+
+	collections, err := p.GetCollections("1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(collections) != 2 {
+		t.Fatalf("expected 2 collections, got %d", len(collections))
+	}
+
+	if collections[0].Title != "Alien Collection" || collections[0].Count() != 4 || collections[0].Smart.Bool() {
+		t.Errorf("unexpected regular collection: %+v", collections[0])
+	}
+
+	if collections[0].CollectionMode.String() != "2" {
+		t.Errorf("expected collection mode 2, got %s", collections[0].CollectionMode)
+	}
+
+	if !collections[1].Smart.Bool() || collections[1].Count() != 37 {
+		t.Errorf("unexpected smart collection: %+v", collections[1])
+	}
+}
+
+func TestEditCollection(t *testing.T) {
+	var requests []string
+
+	server, p := newCollectionServer(t, &requests)
+	defer server.Close()
+
+	collection, err := p.CreateCollection("1", "Heist Movies", "movie", []string{"10", "11"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if collection.RatingKey != "5003" {
+		t.Fatalf("expected new collection 5003, got %s", collection.RatingKey)
+	}
+
+	if err := p.AddToCollection(collection.RatingKey, []string{"12"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.RemoveFromCollection(collection.RatingKey, "10"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.SetCollectionMode(collection.RatingKey, CollectionModeHideItems); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.SetCollectionMode(collection.RatingKey, 5); err == nil {
+		t.Error("expected an invalid collection mode to fail")
+	}
+
+	if err := p.EditCollectionSummary("1", collection.RatingKey, "crews and vaults"); err != nil {
+		t.Fatal(err)
+	}
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	if err := p.UploadCollectionPoster(collection.RatingKey, png); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.DeleteCollection(collection.RatingKey); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"GET /identity",
+		"POST /library/collections",
+		"GET /identity",
+		"PUT /library/collections/5003/items",
+		"DELETE /library/collections/5003/items/10",
+		"PUT /library/metadata/5003/prefs",
+		"PUT /library/sections/1/all",
+		"POST /library/metadata/5003/posters",
+		"DELETE /library/collections/5003",
+	}
+
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+}
@@ -0,0 +1,480 @@
+package plex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDownloadConcurrency = 2
+	defaultProgressInterval    = 500 * time.Millisecond
+
+	// partialDownloadSuffix is appended to files that are being downloaded
+	partialDownloadSuffix = ".part"
+)
+
+// DownloadOptions configures DownloadAll
+type DownloadOptions struct {
+	// CreateFolders puts the files of an item in {show}/{season}, {artist}/{album} or {movie} folders
+	CreateFolders bool
+	// SkipIfExists skips files that already exist instead of downloading them again
+	SkipIfExists bool
+	// DisableResume discards partial files of a previous download instead of resuming them
+	DisableResume bool
+	// Concurrency is the number of files downloaded at once. Defaults to 2
+	Concurrency int
+	// OnProgress is called while a file is written, at most every ProgressInterval,
+	// and once more when the file is done. It is called from several goroutines
+	OnProgress func(progress DownloadProgress)
+	// ProgressInterval defaults to 500ms
+	ProgressInterval time.Duration
+	// NameTemplate names the files relative to the download path instead of their original
+	// name, i.e. NameTemplateShow. See RenderName. CreateFolders is ignored when it is set
+	NameTemplate string
+	// ManifestPath is where a json manifest of the downloaded files is written, if set
+	ManifestPath string
+	// RateLimiter caps the combined bandwidth of the files. It can be shared with other downloads
+	RateLimiter *RateLimiter
+	// PerFileRate caps the bandwidth of each file, in bytes per second. 0 means no limit
+	PerFileRate int64
+	// Window restricts the downloads to a daily period. Files wait for the window to open
+	// and are interrupted when it closes, to be resumed when it opens again
+	Window *TimeWindow
+}
+
+// DownloadProgress is the state of a file being downloaded
+type DownloadProgress struct {
+	RatingKey string
+	// Path is where the file is saved once complete
+	Path string
+	// Written is the number of bytes on disk, including a resumed partial file
+	Written int64
+	// Total is the expected size of the file, 0 if the server did not report it
+	Total int64
+	Done  bool
+}
+
+// DownloadResult is the outcome of a file of DownloadAll
+type DownloadResult struct {
+	Item Metadata
+	Part Part
+	Path string
+	Size int64
+	// Skipped is true when the file already existed and SkipIfExists was set
+	Skipped bool
+	// Resumed is true when a partial file of a previous download was completed
+	Resumed bool
+	Err     error
+}
+
+// DownloadAll downloads every part of items to path. Files are written next to their destination
+// with a .part suffix and renamed once their size is verified, so an interrupted download is
+// resumed by the next call. It returns a result per file and the first error, if any
+func (p *Plex) DownloadAll(items []Metadata, path string, opts DownloadOptions) ([]DownloadResult, error) {
+	return p.DownloadAllContext(context.Background(), items, path, opts)
+}
+
+// DownloadAllContext is like DownloadAll but uses ctx to cancel the requests or bound their duration
+func (p *Plex) DownloadAllContext(ctx context.Context, items []Metadata, path string, opts DownloadOptions) ([]DownloadResult, error) {
+	if opts.PerFileRate < 0 {
+		return nil, fmt.Errorf("invalid per file rate %d, expected a positive number of bytes per second", opts.PerFileRate)
+	}
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultDownloadConcurrency
+	}
+
+	if opts.ProgressInterval <= 0 {
+		opts.ProgressInterval = defaultProgressInterval
+	}
+
+	var results []DownloadResult
+
+	for _, item := range items {
+		results = append(results, downloadResults(item, path, opts)...)
+	}
+
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				p.downloadPart(ctx, &results[i], opts)
+			}
+		}()
+	}
+
+	sent := 0
+
+dispatch:
+	for ; sent < len(results); sent++ {
+		select {
+		case jobs <- sent:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	// files that were never started are not downloaded
+	for i := sent; i < len(results); i++ {
+		if results[i].Err == nil {
+			results[i].Err = ctx.Err()
+		}
+	}
+
+	if opts.ManifestPath != "" {
+		if err := NewDownloadManifest(path, results).Save(opts.ManifestPath); err != nil {
+			return results, err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			return results, result.Err
+		}
+	}
+
+	return results, nil
+}
+
+// downloadResults lists the files of the parts of item
+func downloadResults(item Metadata, path string, opts DownloadOptions) []DownloadResult {
+	var parts []Part
+
+	for _, media := range item.Media {
+		parts = append(parts, media.Part...)
+	}
+
+	results := make([]DownloadResult, len(parts))
+
+	for i, part := range parts {
+		results[i] = DownloadResult{Item: item, Part: part}
+
+		if opts.NameTemplate == "" {
+			results[i].Path = filepath.Join(downloadDir(item, path, opts.CreateFolders), partFilename(part))
+			continue
+		}
+
+		template := opts.NameTemplate
+
+		// keep the parts of a multi-part item apart
+		if len(parts) > 1 && !strings.Contains(template, "{part") {
+			ext := filepath.Ext(template)
+			template = strings.TrimSuffix(template, ext) + " - pt{part}" + ext
+		}
+
+		name, err := RenderName(template, item, part, i+1)
+
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		results[i].Path = filepath.Join(path, name)
+	}
+
+	return results
+}
+
+// downloadDir is the folder the files of meta are saved to
+func downloadDir(meta Metadata, path string, createFolders bool) string {
+	if !createFolders {
+		return path
+	}
+
+	if meta.ParentTitle != "" && meta.GrandparentTitle != "" { // for tv shows and music
+		return filepath.Join(path, meta.GrandparentTitle, meta.ParentTitle)
+	}
+
+	// for movies
+	return filepath.Join(path, meta.Title)
+}
+
+// partFilename is the original filename of a part. The server may run on windows
+func partFilename(part Part) string {
+	return part.File[strings.LastIndexAny(part.File, `/\`)+1:]
+}
+
+func (p *Plex) downloadPart(ctx context.Context, result *DownloadResult, opts DownloadOptions) {
+	// the file could not be named
+	if result.Err != nil {
+		return
+	}
+
+	if opts.Window == nil || opts.Window.Start == opts.Window.End {
+		p.downloadPartOnce(ctx, result, opts)
+		return
+	}
+
+	for {
+		if err := opts.Window.wait(ctx); err != nil {
+			result.Err = err
+			return
+		}
+
+		windowCtx, cancel := context.WithDeadline(ctx, opts.Window.NextEnd(time.Now()))
+
+		p.downloadPartOnce(windowCtx, result, opts)
+
+		closed := windowCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+
+		cancel()
+
+		if result.Err == nil || !closed {
+			return
+		}
+
+		// the window closed during the download, resume it once it opens again
+		result.Err = nil
+	}
+}
+
+func (p *Plex) downloadPartOnce(ctx context.Context, result *DownloadResult, opts DownloadOptions) {
+	if ctx.Err() != nil {
+		result.Err = ctx.Err()
+		return
+	}
+
+	if info, err := os.Stat(result.Path); err == nil && opts.SkipIfExists {
+		result.Skipped = true
+		result.Size = info.Size()
+
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(result.Path), 0700); err != nil {
+		result.Err = err
+		return
+	}
+
+	tmp := result.Path + partialDownloadSuffix
+
+	if opts.DisableResume {
+		os.Remove(tmp)
+	}
+
+	size, resumed, err := p.downloadToFile(ctx, result, tmp, opts)
+
+	if err != nil {
+		result.Err = fmt.Errorf("failed to download %s: %w", result.Path, err)
+		return
+	}
+
+	if err := os.Rename(tmp, result.Path); err != nil {
+		result.Err = err
+		return
+	}
+
+	result.Size = size
+	result.Resumed = resumed
+}
+
+// downloadToFile completes the partial file tmp and verifies its size
+func (p *Plex) downloadToFile(ctx context.Context, result *DownloadResult, tmp string, opts DownloadOptions) (int64, bool, error) {
+	total := int64(result.Part.Size)
+
+	var offset int64
+
+	if info, err := os.Stat(tmp); err == nil {
+		offset = info.Size()
+	}
+
+	// a partial file larger than the part is not ours to resume
+	if total > 0 && offset > total {
+		offset = 0
+	}
+
+	resumed := offset > 0
+
+	if total == 0 || offset < total {
+		appended, err := p.fetchPart(ctx, result, tmp, offset, opts)
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		resumed = appended
+	}
+
+	info, err := os.Stat(tmp)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	if total > 0 && info.Size() != total {
+		// keep a short file so it can be resumed
+		if info.Size() > total {
+			os.Remove(tmp)
+		}
+
+		return 0, false, fmt.Errorf("expected %d bytes, got %d", total, info.Size())
+	}
+
+	report(opts, DownloadProgress{
+		RatingKey: result.Item.RatingKey,
+		Path:      result.Path,
+		Written:   info.Size(),
+		Total:     total,
+		Done:      true,
+	})
+
+	return info.Size(), resumed, nil
+}
+
+// fetchPart requests the part from offset and writes it to tmp. It reports whether the
+// server sent the rest of the file, which was appended to tmp
+func (p *Plex) fetchPart(ctx context.Context, result *DownloadResult, tmp string, offset int64, opts DownloadOptions) (bool, error) {
+	h := p.Headers
+
+	if offset > 0 {
+		h.Range = fmt.Sprintf("bytes=%d-", offset)
+	}
+
+	query := fmt.Sprintf("%s%s?download=1", p.URL, result.Part.Key)
+
+	resp, err := p.grab(ctx, query, h)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, err := contentRangeStart(resp.Header.Get("Content-Range"))
+
+		if err != nil || start != offset {
+			return false, errors.New("server returned an unexpected range " + resp.Header.Get("Content-Range"))
+		}
+
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// the server ignored the range and sent the whole file
+		offset = 0
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial file is not a prefix of the part, start over
+		if err := os.Remove(tmp); err != nil {
+			return false, err
+		}
+
+		resp.Body.Close()
+
+		return p.fetchPart(ctx, result, tmp, 0, opts)
+	default:
+		return false, newAPIError(resp)
+	}
+
+	out, err := os.OpenFile(tmp, flags, 0644)
+
+	if err != nil {
+		return false, err
+	}
+
+	writer := &progressWriter{
+		opts: opts,
+		progress: DownloadProgress{
+			RatingKey: result.Item.RatingKey,
+			Path:      result.Path,
+			Written:   offset,
+			Total:     int64(result.Part.Size),
+		},
+	}
+
+	var body io.Reader = resp.Body
+
+	if limiters := opts.limiters(); len(limiters) > 0 {
+		body = &rateLimitedReader{ctx: ctx, reader: body, limiters: limiters}
+	}
+
+	_, err = io.Copy(io.MultiWriter(out, writer), body)
+
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	return offset > 0, err
+}
+
+// contentRangeStart parses the first byte of a Content-Range header, i.e. "bytes 100-199/200"
+func contentRangeStart(contentRange string) (int64, error) {
+	if !strings.HasPrefix(contentRange, "bytes ") {
+		return 0, errors.New("invalid content range")
+	}
+
+	byteRange := strings.TrimPrefix(contentRange, "bytes ")
+
+	dash := strings.Index(byteRange, "-")
+
+	if dash < 0 {
+		return 0, errors.New("invalid content range")
+	}
+
+	return strconv.ParseInt(byteRange[:dash], 10, 64)
+}
+
+// progressWriter counts the bytes written and reports them to OnProgress
+type progressWriter struct {
+	opts       DownloadOptions
+	progress   DownloadProgress
+	lastReport time.Time
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	w.progress.Written += int64(len(b))
+
+	if now := time.Now(); now.Sub(w.lastReport) >= w.opts.ProgressInterval {
+		w.lastReport = now
+
+		report(w.opts, w.progress)
+	}
+
+	return len(b), nil
+}
+
+func report(opts DownloadOptions, progress DownloadProgress) {
+	if opts.OnProgress != nil {
+		opts.OnProgress(progress)
+	}
+}
+
+// limiters returns the rate limiters of a file
+func (opts DownloadOptions) limiters() []*RateLimiter {
+	var limiters []*RateLimiter
+
+	if opts.RateLimiter != nil {
+		limiters = append(limiters, opts.RateLimiter)
+	}
+
+	if opts.PerFileRate > 0 {
+		limiters = append(limiters, newRateLimiter(opts.PerFileRate))
+	}
+
+	return limiters
+}
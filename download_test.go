@@ -0,0 +1,289 @@
+package plex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testFiles = map[string][]byte{
+	"/library/parts/1/file.mkv": bytes.Repeat([]byte("pilot "), 1000),
+	"/library/parts/2/file.mkv": bytes.Repeat([]byte("cat's in the bag "), 1000),
+}
+
+func testDownloadItems() []Metadata {
+	return []Metadata{
+		{
+			RatingKey:        "10",
+			Title:            "Pilot",
+			ParentTitle:      "Season 1",
+			GrandparentTitle: "Breaking Bad",
+			Media: []Media{{Part: []Part{{
+				Key:  "/library/parts/1/file.mkv",
+				File: "/data/tv/Breaking Bad/Season 1/S01E01.mkv",
+				Size: len(testFiles["/library/parts/1/file.mkv"]),
+			}}}},
+		},
+		{
+			RatingKey:        "11",
+			Title:            "Cat's in the Bag...",
+			ParentTitle:      "Season 1",
+			GrandparentTitle: "Breaking Bad",
+			Media: []Media{{Part: []Part{{
+				Key:  "/library/parts/2/file.mkv",
+				File: `D:\tv\Breaking Bad\Season 1\S01E02.mkv`,
+				Size: len(testFiles["/library/parts/2/file.mkv"]),
+			}}}},
+		},
+	}
+}
+
+// newDownloadServer serves testFiles with range support and records the ranges requested
+func newDownloadServer(ranges *sync.Map) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := testFiles[r.URL.Path]
+
+		if !ok || r.URL.Query().Get("download") != "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if ranges != nil {
+			ranges.Store(r.URL.Path, r.Header.Get("Range"))
+		}
+
+		http.ServeContent(w, r, "file.mkv", time.Time{}, bytes.NewReader(content))
+	}))
+}
+
+func TestDownloadAll(t *testing.T) {
+	server := newDownloadServer(nil)
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "plex-download")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	var (
+		mu   sync.Mutex
+		done []string
+	)
+
+	results, err := p.DownloadAll(testDownloadItems(), dir, DownloadOptions{
+		CreateFolders: true,
+		Concurrency:   2,
+		OnProgress: func(progress DownloadProgress) {
+			if progress.Done {
+				mu.Lock()
+				done = append(done, progress.RatingKey)
+				mu.Unlock()
+			}
+		},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 || len(done) != 2 {
+		t.Fatalf("expected 2 downloads, got %d results and %d done", len(results), len(done))
+	}
+
+	for i, name := range []string{"S01E01.mkv", "S01E02.mkv"} {
+		path := filepath.Join(dir, "Breaking Bad", "Season 1", name)
+
+		if results[i].Path != path {
+			t.Errorf("expected %s, got %s", path, results[i].Path)
+		}
+
+		content, err := ioutil.ReadFile(path)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(content, testFiles[results[i].Part.Key]) {
+			t.Errorf("%s has unexpected content", name)
+		}
+
+		if _, err := os.Stat(path + partialDownloadSuffix); !os.IsNotExist(err) {
+			t.Errorf("expected the partial file of %s to be renamed", name)
+		}
+	}
+}
+
+func TestDownloadAllResume(t *testing.T) {
+	var ranges sync.Map
+
+	server := newDownloadServer(&ranges)
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "plex-download")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	items := testDownloadItems()
+
+	// the first episode was interrupted, the second is already complete
+	partial := testFiles["/library/parts/1/file.mkv"][:1000]
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "S01E01.mkv"+partialDownloadSuffix), partial, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "S01E02.mkv"), []byte("existing"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := p.DownloadAll(items, dir, DownloadOptions{SkipIfExists: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !results[0].Resumed || results[0].Size != int64(items[0].Media[0].Part[0].Size) {
+		t.Errorf("expected the first episode to be resumed, got %+v", results[0])
+	}
+
+	if requested, _ := ranges.Load("/library/parts/1/file.mkv"); requested != "bytes=1000-" {
+		t.Errorf("expected a range request from byte 1000, got %v", requested)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "S01E01.mkv"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(content, testFiles["/library/parts/1/file.mkv"]) {
+		t.Error("resumed file has unexpected content")
+	}
+
+	if !results[1].Skipped {
+		t.Errorf("expected the existing episode to be skipped, got %+v", results[1])
+	}
+}
+
+func TestDownloadAllSizeMismatch(t *testing.T) {
+	server := newDownloadServer(nil)
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "plex-download")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	items := testDownloadItems()[:1]
+
+	items[0].Media[0].Part[0].Size++
+
+	results, err := p.DownloadAll(items, dir, DownloadOptions{})
+
+	if err == nil || !strings.Contains(err.Error(), "expected") {
+		t.Fatalf("expected a size mismatch, got %v", err)
+	}
+
+	if results[0].Err == nil {
+		t.Error("expected the result to carry the error")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "S01E01.mkv")); !os.IsNotExist(err) {
+		t.Error("expected an incomplete file not to be renamed")
+	}
+}
+
+func TestDownloadAllCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the first file cancels the download, the second is never started
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "plex-download")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	manifestPath := filepath.Join(dir, "manifest.json")
+
+	results, err := p.DownloadAllContext(ctx, testDownloadItems(), dir, DownloadOptions{Concurrency: 1, ManifestPath: manifestPath})
+
+	if err != context.Canceled {
+		t.Fatalf("expected the download to be canceled, got %v", err)
+	}
+
+	for _, result := range results {
+		if result.Err == nil {
+			t.Errorf("expected %s to fail", result.Path)
+		}
+	}
+
+	data, err := ioutil.ReadFile(manifestPath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var manifest DownloadManifest
+
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range manifest.Files {
+		if entry.Status != ManifestStatusFailed {
+			t.Errorf("expected %s to be failed in the manifest, got %s", entry.Path, entry.Status)
+		}
+	}
+}
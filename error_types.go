@@ -1,5 +1,16 @@
 package plex
 
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
 // ErrorInvalidToken a constant to help check invalid token errors
 const (
 	ErrorInvalidToken       = "invalid token"
@@ -16,3 +27,140 @@ const (
 	ErrorFailedToSetWebhook = "failed to set webhook"
 	ErrorWebhook            = "webhook error: %s"
 )
+
+// Sentinel errors that can be checked with errors.Is against errors returned by this package
+var (
+	// ErrUnauthorized the token is not allowed to access the resource (401 or 403)
+	ErrUnauthorized = errors.New(ErrorNotAuthorized)
+	// ErrNotFound the resource does not exist (404)
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidToken plex.tv did not accept the token
+	ErrInvalidToken = errors.New(ErrorInvalidToken)
+	// ErrRateLimited too many requests were made (429)
+	ErrRateLimited = errors.New("rate limited")
+	// ErrServerUnreachable the request never received a response
+	ErrServerUnreachable = errors.New("server unreachable")
+	// ErrPINNotAuthorized the pin has not been linked to an account yet
+	ErrPINNotAuthorized = errors.New(ErrorPINNotAuthorized)
+)
+
+// plex.tv error code for a token that could not be authenticated
+const plexTVInvalidTokenCode = 1001
+
+// maxErrorBodyLength is how much of a response body is kept in an APIError
+const maxErrorBodyLength = 1024
+
+// APIError is returned when plex.tv or a Plex Media Server replies with an unexpected status code
+type APIError struct {
+	// StatusCode is the http status code, i.e. 404
+	StatusCode int
+	// Status is the http status, i.e. "404 Not Found"
+	Status string
+	// Method is the http method of the request
+	Method string
+	// Endpoint is the requested url without its query
+	Endpoint string
+	// Code and Message are the first error reported by plex.tv, if any
+	Code    int
+	Message string
+	// Body is an excerpt of the response body
+	Body string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s: %s", e.Status, e.Message)
+	}
+
+	return e.Status
+}
+
+// Is allows the sentinel errors to match an APIError via errors.Is
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrInvalidToken:
+		return e.StatusCode == http.StatusUnprocessableEntity || e.Code == plexTVInvalidTokenCode
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+
+	return false
+}
+
+// RequestError is returned when a request did not receive a response, i.e. the server is offline
+type RequestError struct {
+	Method   string
+	Endpoint string
+	Err      error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrServerUnreachable, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports the error as ErrServerUnreachable
+func (e *RequestError) Is(target error) bool {
+	return target == ErrServerUnreachable
+}
+
+// newAPIError creates an APIError from an unexpected response. The body is read
+// but not closed
+func newAPIError(resp *http.Response) error {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+		apiErr.Endpoint = endpointOf(resp.Request)
+	}
+
+	if resp.Body == nil {
+		return apiErr
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+
+	if err != nil {
+		return apiErr
+	}
+
+	apiErr.Body = string(body)
+
+	// plex.tv describes errors as {"errors":[...]} or <errors><error .../></errors>
+	var tvErrors struct {
+		XMLName xml.Name        `json:"-" xml:"errors"`
+		Errors  []ErrorResponse `json:"errors" xml:"error"`
+	}
+
+	if json.Unmarshal(body, &tvErrors) != nil {
+		xml.Unmarshal(body, &tvErrors)
+	}
+
+	if len(tvErrors.Errors) > 0 {
+		apiErr.Code = tvErrors.Errors[0].Code
+		apiErr.Message = tvErrors.Errors[0].Message
+	}
+
+	return apiErr
+}
+
+// endpointOf returns the url of a request without the query as it may hold a token
+func endpointOf(req *http.Request) string {
+	endpoint := *req.URL
+
+	endpoint.RawQuery = ""
+	endpoint.User = nil
+
+	return strings.TrimSuffix(endpoint.String(), "?")
+}
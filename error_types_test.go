@@ -0,0 +1,89 @@
+package plex
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIErrorSentinels(t *testing.T) {
+	tests := []struct {
+		code     int
+		body     string
+		sentinel error
+	}{
+		{http.StatusUnauthorized, `{"errors":[{"code":1001,"message":"User could not be authenticated","status":401}]}`, ErrUnauthorized},
+		{http.StatusUnauthorized, `{"errors":[{"code":1001,"message":"User could not be authenticated","status":401}]}`, ErrInvalidToken},
+		{http.StatusUnprocessableEntity, "", ErrInvalidToken},
+		{http.StatusNotFound, `<errors><error code="1020" message="Resource not found" status="404"/></errors>`, ErrNotFound},
+		{http.StatusTooManyRequests, "", ErrRateLimited},
+	}
+
+	for _, test := range tests {
+		server, p := newTestServer(test.code, test.body)
+
+		_, err := p.GetLibraries()
+
+		server.Close()
+
+		if !errors.Is(err, test.sentinel) {
+			t.Errorf("status %d: expected %v, got %v", test.code, test.sentinel, err)
+		}
+
+		var apiErr *APIError
+
+		if !errors.As(err, &apiErr) {
+			t.Errorf("status %d: expected an APIError, got %T", test.code, err)
+			continue
+		}
+
+		if apiErr.StatusCode != test.code {
+			t.Errorf("expected status code %d, got %d", test.code, apiErr.StatusCode)
+		}
+
+		if apiErr.Endpoint != server.URL+"/library/sections" {
+			t.Errorf("unexpected endpoint %s", apiErr.Endpoint)
+		}
+	}
+}
+
+func TestAPIErrorPlexTVMessage(t *testing.T) {
+	server, p := newTestServer(http.StatusUnauthorized, `{"errors":[{"code":1001,"message":"User could not be authenticated","status":401}]}`)
+	defer server.Close()
+
+	_, err := p.GetWebhooks()
+
+	var apiErr *APIError
+
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+
+	if apiErr.Code != 1001 || apiErr.Message != "User could not be authenticated" {
+		t.Errorf("unexpected plex.tv error: %d %s", apiErr.Code, apiErr.Message)
+	}
+}
+
+func TestServerUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.GetSessions()
+
+	if !errors.Is(err, ErrServerUnreachable) {
+		t.Errorf("expected ErrServerUnreachable, got %v", err)
+	}
+
+	var requestErr *RequestError
+
+	if !errors.As(err, &requestErr) || requestErr.Method != http.MethodGet {
+		t.Errorf("expected a RequestError, got %T", err)
+	}
+}
@@ -0,0 +1,358 @@
+package plex
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultEventBufferSize = 64
+
+// DeliveryPolicy decides what happens to an event when a consumer's buffer is full
+type DeliveryPolicy int
+
+const (
+	// DropNewest discards the incoming event
+	DropNewest DeliveryPolicy = iota
+	// DropOldest discards the oldest buffered event to make room for the incoming one
+	DropOldest
+	// Block waits for the consumer, which stalls every consumer and the websocket reader
+	Block
+)
+
+// Event is a notification delivered by an EventStream
+type Event struct {
+	// Type is one of the Notification constants, i.e. NotificationPlaying
+	Type         string
+	Notification NotificationContainer
+	ReceivedAt   time.Time
+}
+
+// EventFilter restricts the events a consumer receives. Empty fields match every event.
+// When SectionIDs or SessionKeys are set, only the matching timeline entries or play
+// session notifications are kept and events without any are skipped
+type EventFilter struct {
+	Types       []string
+	SectionIDs  []int64
+	SessionKeys []string
+}
+
+// StreamOptions configures a consumer of an EventStream
+type StreamOptions struct {
+	// BufferSize is the capacity of the consumer's channel. Defaults to 64
+	BufferSize int
+	// Policy applies when the buffer is full. Defaults to DropNewest
+	Policy DeliveryPolicy
+	Filter EventFilter
+}
+
+// StreamStats are the delivery metrics of a consumer
+type StreamStats struct {
+	// Delivered is the number of events handed to the channel
+	Delivered uint64
+	// Dropped is the number of events discarded because the buffer was full
+	Dropped uint64
+	// Blocked is the number of events that had to wait for the consumer
+	Blocked uint64
+	// Pending is the number of buffered events not yet received
+	Pending int
+	// Capacity is the size of the buffer
+	Capacity int
+}
+
+// EventStream fans notifications out to any number of consumers, each with its own
+// buffer, delivery policy and filter.
+//
+//	stream := plexConnection.NotificationStream(ctx, plex.SubscribeOptions{})
+//
+//	sessions := stream.Subscribe(plex.StreamOptions{
+//		Filter: plex.EventFilter{Types: []string{plex.NotificationPlaying}},
+//	})
+//
+//	for event := range sessions.Events() {
+//		fmt.Println(event.Notification.PlaySessionStateNotification[0].State)
+//	}
+type EventStream struct {
+	mu        sync.Mutex
+	consumers map[*EventSubscription]struct{}
+	closed    bool
+	err       error
+}
+
+// EventSubscription is a consumer of an EventStream
+type EventSubscription struct {
+	// the counters are updated atomically and must stay first to be 64-bit aligned on 32-bit platforms
+	delivered uint64
+	dropped   uint64
+	blocked   uint64
+
+	stream *EventStream
+	events chan Event
+	policy DeliveryPolicy
+	filter EventFilter
+
+	// sendMu guards sending on and closing events
+	sendMu    sync.Mutex
+	done      chan struct{}
+	closeOnce sync.Once
+	closed    bool
+}
+
+// NewEventStream creates a stream that is fed with Publish
+func NewEventStream() *EventStream {
+	return &EventStream{
+		consumers: map[*EventSubscription]struct{}{},
+	}
+}
+
+// NotificationStream subscribes to the notifications of your server and publishes them
+// to the returned stream until ctx is done. Every consumer's channel is closed afterwards
+func (p *Plex) NotificationStream(ctx context.Context, opts SubscribeOptions) *EventStream {
+	stream := NewEventStream()
+
+	events := NewNotificationEvents()
+
+	events.all = func(n NotificationContainer) {
+		stream.Publish(Event{
+			Type:         n.Type,
+			Notification: n,
+			ReceivedAt:   time.Now(),
+		})
+	}
+
+	go func() {
+		err := p.SubscribeToNotificationsContext(ctx, events, opts)
+
+		stream.close(err)
+	}()
+
+	return stream
+}
+
+// Subscribe adds a consumer to the stream. Subscribing to a closed stream returns a closed channel
+func (s *EventStream) Subscribe(opts StreamOptions) *EventSubscription {
+	size := opts.BufferSize
+
+	if size <= 0 {
+		size = defaultEventBufferSize
+	}
+
+	sub := &EventSubscription{
+		stream: s,
+		events: make(chan Event, size),
+		policy: opts.Policy,
+		filter: opts.Filter,
+		done:   make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		sub.shutdown()
+		return sub
+	}
+
+	s.consumers[sub] = struct{}{}
+
+	return sub
+}
+
+// Publish delivers an event to every consumer whose filter matches
+func (s *EventStream) Publish(event Event) {
+	s.mu.Lock()
+
+	consumers := make([]*EventSubscription, 0, len(s.consumers))
+
+	for sub := range s.consumers {
+		consumers = append(consumers, sub)
+	}
+
+	s.mu.Unlock()
+
+	for _, sub := range consumers {
+		if filtered, ok := sub.filter.apply(event); ok {
+			sub.deliver(filtered)
+		}
+	}
+}
+
+// Err returns the error that ended the stream, if any
+func (s *EventStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// Close removes every consumer and closes their channels
+func (s *EventStream) Close() {
+	s.close(nil)
+}
+
+func (s *EventStream) close(err error) {
+	s.mu.Lock()
+
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+
+	s.closed = true
+	s.err = err
+
+	consumers := s.consumers
+
+	s.consumers = map[*EventSubscription]struct{}{}
+
+	s.mu.Unlock()
+
+	for sub := range consumers {
+		sub.shutdown()
+	}
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// subscription or the stream is closed
+func (sub *EventSubscription) Events() <-chan Event {
+	return sub.events
+}
+
+// Stats returns the delivery metrics of the consumer
+func (sub *EventSubscription) Stats() StreamStats {
+	return StreamStats{
+		Delivered: atomic.LoadUint64(&sub.delivered),
+		Dropped:   atomic.LoadUint64(&sub.dropped),
+		Blocked:   atomic.LoadUint64(&sub.blocked),
+		Pending:   len(sub.events),
+		Capacity:  cap(sub.events),
+	}
+}
+
+// Close removes the consumer from the stream and closes its channel
+func (sub *EventSubscription) Close() {
+	sub.stream.mu.Lock()
+	delete(sub.stream.consumers, sub)
+	sub.stream.mu.Unlock()
+
+	sub.shutdown()
+}
+
+func (sub *EventSubscription) shutdown() {
+	sub.closeOnce.Do(func() {
+		// unblock a pending delivery before taking the send lock
+		close(sub.done)
+
+		sub.sendMu.Lock()
+		defer sub.sendMu.Unlock()
+
+		sub.closed = true
+		close(sub.events)
+	})
+}
+
+func (sub *EventSubscription) deliver(event Event) {
+	sub.sendMu.Lock()
+	defer sub.sendMu.Unlock()
+
+	if sub.closed {
+		return
+	}
+
+	select {
+	case sub.events <- event:
+		atomic.AddUint64(&sub.delivered, 1)
+		return
+	default:
+	}
+
+	switch sub.policy {
+	case Block:
+		atomic.AddUint64(&sub.blocked, 1)
+
+		select {
+		case sub.events <- event:
+			atomic.AddUint64(&sub.delivered, 1)
+		case <-sub.done:
+		}
+	case DropOldest:
+		for {
+			select {
+			case sub.events <- event:
+				atomic.AddUint64(&sub.delivered, 1)
+				return
+			default:
+			}
+
+			select {
+			case <-sub.events:
+				atomic.AddUint64(&sub.dropped, 1)
+			default:
+			}
+		}
+	default:
+		atomic.AddUint64(&sub.dropped, 1)
+	}
+}
+
+// apply returns the event reduced to the entries matching the filter
+func (f EventFilter) apply(event Event) (Event, bool) {
+	if len(f.Types) > 0 && !containsString(f.Types, event.Type) {
+		return event, false
+	}
+
+	if len(f.SectionIDs) > 0 {
+		var entries []TimelineEntry
+
+		for _, entry := range event.Notification.TimelineEntry {
+			if containsInt64(f.SectionIDs, entry.SectionID) {
+				entries = append(entries, entry)
+			}
+		}
+
+		if len(entries) == 0 {
+			return event, false
+		}
+
+		event.Notification.TimelineEntry = entries
+	}
+
+	if len(f.SessionKeys) > 0 {
+		var sessions []PlaySessionStateNotification
+
+		for _, session := range event.Notification.PlaySessionStateNotification {
+			if containsString(f.SessionKeys, session.SessionKey) {
+				sessions = append(sessions, session)
+			}
+		}
+
+		if len(sessions) == 0 {
+			return event, false
+		}
+
+		event.Notification.PlaySessionStateNotification = sessions
+	}
+
+	return event, true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsInt64(values []int64, value int64) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
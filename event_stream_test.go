@@ -0,0 +1,214 @@
+package plex
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func playingEvent(sessionKey string) Event {
+	return Event{
+		Type: NotificationPlaying,
+		Notification: NotificationContainer{
+			Type:                         NotificationPlaying,
+			PlaySessionStateNotification: []PlaySessionStateNotification{{SessionKey: sessionKey, State: "playing"}},
+		},
+	}
+}
+
+func TestEventStreamPolicies(t *testing.T) {
+	stream := NewEventStream()
+	defer stream.Close()
+
+	dropNewest := stream.Subscribe(StreamOptions{BufferSize: 2, Policy: DropNewest})
+	dropOldest := stream.Subscribe(StreamOptions{BufferSize: 2, Policy: DropOldest})
+
+	for _, key := range []string{"1", "2", "3"} {
+		stream.Publish(playingEvent(key))
+	}
+
+	stats := dropNewest.Stats()
+
+	if stats.Delivered != 2 || stats.Dropped != 1 || stats.Pending != 2 || stats.Capacity != 2 {
+		t.Errorf("unexpected drop newest stats: %+v", stats)
+	}
+
+	if first := <-dropNewest.Events(); first.Notification.PlaySessionStateNotification[0].SessionKey != "1" {
+		t.Errorf("expected the newest event to be dropped, got %+v", first)
+	}
+
+	if first := <-dropOldest.Events(); first.Notification.PlaySessionStateNotification[0].SessionKey != "2" {
+		t.Errorf("expected the oldest event to be dropped, got %+v", first)
+	}
+
+	if stats := dropOldest.Stats(); stats.Delivered != 3 || stats.Dropped != 1 {
+		t.Errorf("unexpected drop oldest stats: %+v", stats)
+	}
+}
+
+func TestEventStreamBlock(t *testing.T) {
+	stream := NewEventStream()
+
+	sub := stream.Subscribe(StreamOptions{BufferSize: 1, Policy: Block})
+
+	stream.Publish(playingEvent("1"))
+
+	published := make(chan struct{})
+
+	go func() {
+		stream.Publish(playingEvent("2"))
+		close(published)
+	}()
+
+	select {
+	case <-published:
+		t.Fatal("expected publish to block on a full buffer")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	<-sub.Events()
+	<-published
+
+	if stats := sub.Stats(); stats.Blocked != 1 || stats.Delivered != 2 || stats.Dropped != 0 {
+		t.Errorf("unexpected block stats: %+v", stats)
+	}
+
+	// the buffer holds event 2 so this delivery waits until the consumer is closed
+	published = make(chan struct{})
+
+	go func() {
+		stream.Publish(playingEvent("3"))
+		close(published)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	sub.Close()
+
+	<-published
+
+	for range sub.Events() {
+	}
+}
+
+func TestEventStreamFilters(t *testing.T) {
+	stream := NewEventStream()
+
+	session := stream.Subscribe(StreamOptions{Filter: EventFilter{SessionKeys: []string{"12"}}})
+	section := stream.Subscribe(StreamOptions{Filter: EventFilter{SectionIDs: []int64{2}}})
+	activity := stream.Subscribe(StreamOptions{Filter: EventFilter{Types: []string{NotificationActivity}}})
+
+	stream.Publish(Event{
+		Type: NotificationPlaying,
+		Notification: NotificationContainer{
+			Type: NotificationPlaying,
+			PlaySessionStateNotification: []PlaySessionStateNotification{
+				{SessionKey: "11"},
+				{SessionKey: "12"},
+			},
+		},
+	})
+
+	stream.Publish(Event{
+		Type: NotificationTimeline,
+		Notification: NotificationContainer{
+			Type:          NotificationTimeline,
+			TimelineEntry: []TimelineEntry{{SectionID: 1}, {SectionID: 2, ItemID: 20}},
+		},
+	})
+
+	stream.Publish(Event{Type: NotificationActivity})
+
+	stream.Close()
+
+	var sessionEvents, sectionEvents, activityEvents []Event
+
+	for event := range session.Events() {
+		sessionEvents = append(sessionEvents, event)
+	}
+
+	for event := range section.Events() {
+		sectionEvents = append(sectionEvents, event)
+	}
+
+	for event := range activity.Events() {
+		activityEvents = append(activityEvents, event)
+	}
+
+	if len(sessionEvents) != 1 || len(sessionEvents[0].Notification.PlaySessionStateNotification) != 1 {
+		t.Errorf("expected only session 12, got %+v", sessionEvents)
+	}
+
+	if len(sectionEvents) != 1 || sectionEvents[0].Notification.TimelineEntry[0].ItemID != 20 {
+		t.Errorf("expected only section 2, got %+v", sectionEvents)
+	}
+
+	if len(activityEvents) != 1 || activityEvents[0].Type != NotificationActivity {
+		t.Errorf("expected only activity events, got %+v", activityEvents)
+	}
+}
+
+func TestNotificationStream(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, []byte(testPlayingNotification))
+
+		// wait for the client to close the connection
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	connected := make(chan struct{})
+
+	stream := p.NotificationStream(ctx, SubscribeOptions{
+		OnStateChange: func(state ConnectionState, err error) {
+			if state == StateConnected {
+				close(connected)
+			}
+		},
+	})
+
+	sub := stream.Subscribe(StreamOptions{})
+
+	event, ok := <-sub.Events()
+
+	if !ok || event.Type != NotificationPlaying || event.Notification.PlaySessionStateNotification[0].SessionKey != "12" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	<-connected
+	cancel()
+
+	// the channel is closed once the subscription ends
+	for range sub.Events() {
+	}
+
+	if err := stream.Err(); err != nil {
+		t.Errorf("expected no error after cancel, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package plex
+
+import (
+	"context"
+	"fmt"
+)
+
+// ExpandMedia resolves the rating key of a show, season, artist, album or collection into
+// every playable item it contains, i.e. the episodes of a show. Movies, episodes and tracks
+// are returned as is
+func (p *Plex) ExpandMedia(ratingKey string) ([]Metadata, error) {
+	return p.ExpandMediaContext(context.Background(), ratingKey)
+}
+
+// ExpandMediaContext is like ExpandMedia but uses ctx to cancel the requests or bound their duration
+func (p *Plex) ExpandMediaContext(ctx context.Context, ratingKey string) ([]Metadata, error) {
+	meta, err := p.GetMetadataContext(ctx, ratingKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(meta.MediaContainer.Metadata) == 0 {
+		return nil, fmt.Errorf("no media found for %s", ratingKey)
+	}
+
+	return p.expand(ctx, meta.MediaContainer.Metadata[0])
+}
+
+// ExpandPlaylist returns the playable items of a playlist
+func (p *Plex) ExpandPlaylist(playlistID int) ([]Metadata, error) {
+	return p.ExpandPlaylistContext(context.Background(), playlistID)
+}
+
+// ExpandPlaylistContext is like ExpandPlaylist but uses ctx to cancel the requests or bound their duration
+func (p *Plex) ExpandPlaylistContext(ctx context.Context, playlistID int) ([]Metadata, error) {
+	playlist, err := p.GetPlaylistContext(ctx, playlistID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return p.expandAll(ctx, playlist.MediaContainer.Metadata)
+}
+
+// expand returns the leaves of item, fetching its children or its media when missing
+func (p *Plex) expand(ctx context.Context, item Metadata) ([]Metadata, error) {
+	switch item.Type {
+	case "show", "season", "artist", "album", "photoalbum":
+		children, err := p.GetMetadataChildrenContext(ctx, item.RatingKey)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return p.expandAll(ctx, children.MediaContainer.Metadata)
+	case "collection":
+		children, err := p.GetCollectionItemsContext(ctx, item.RatingKey)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return p.expandAll(ctx, children.MediaContainer.Metadata)
+	}
+
+	if len(item.Media) > 0 {
+		return []Metadata{item}, nil
+	}
+
+	meta, err := p.GetMetadataContext(ctx, item.RatingKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return meta.MediaContainer.Metadata, nil
+}
+
+func (p *Plex) expandAll(ctx context.Context, items []Metadata) ([]Metadata, error) {
+	var leaves []Metadata
+
+	for _, item := range items {
+		expanded, err := p.expand(ctx, item)
+
+		if err != nil {
+			return nil, err
+		}
+
+		leaves = append(leaves, expanded...)
+	}
+
+	return leaves, nil
+}
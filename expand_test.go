@@ -0,0 +1,155 @@
+package plex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExpandMediaAndDownload(t *testing.T) {
+	episode := func(ratingKey string, index int, partKey string) string {
+		return fmt.Sprintf(`{"ratingKey":"%s","type":"episode","title":"Episode %d","grandparentTitle":"Breaking Bad","parentTitle":"Season 1","parentIndex":1,"index":%d,"Media":[{"Part":[{"key":"%s","file":"/tv/e%d.mkv","size":%d}]}]}`,
+			ratingKey, index, index, partKey, index, len(testFiles[partKey]))
+	}
+
+	responses := map[string]string{
+		"/library/metadata/1":             `{"MediaContainer":{"size":1,"Metadata":[{"ratingKey":"1","type":"show","title":"Breaking Bad"}]}}`,
+		"/library/metadata/1/children":    `{"MediaContainer":{"size":1,"Metadata":[{"ratingKey":"2","type":"season","title":"Season 1","index":1}]}}`,
+		"/library/metadata/2/children":    `{"MediaContainer":{"size":2,"Metadata":[` + episode("10", 1, "/library/parts/1/file.mkv") + `,` + episode("11", 2, "/library/parts/2/file.mkv") + `]}}`,
+		"/library/metadata/5":             `{"MediaContainer":{"size":1,"Metadata":[{"ratingKey":"5","type":"collection","title":"Crime"}]}}`,
+		"/library/collections/5/children": `{"MediaContainer":{"size":2,"Metadata":[{"ratingKey":"1","type":"show","title":"Breaking Bad"},{"ratingKey":"12","type":"movie","title":"El Camino"}]}}`,
+		"/library/metadata/12":            `{"MediaContainer":{"size":1,"Metadata":[{"ratingKey":"12","type":"movie","title":"El Camino","Media":[{"Part":[{"key":"/library/parts/3/file.mkv","file":"/movies/elcamino.mkv"}]}]}]}}`,
+		"/playlists/7/items":              `{"MediaContainer":{"size":1,"Metadata":[` + episode("11", 2, "/library/parts/2/file.mkv") + `]}}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if content, ok := testFiles[r.URL.Path]; ok {
+			http.ServeContent(w, r, "file.mkv", time.Time{}, bytes.NewReader(content))
+			return
+		}
+
+		response, ok := responses[r.URL.Path]
+
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", applicationJson)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := p.ExpandMedia("5")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+
+	for _, item := range items {
+		keys = append(keys, item.RatingKey)
+	}
+
+	if fmt.Sprint(keys) != "[10 11 12]" {
+		t.Errorf("expected the episodes of the show and the movie, got %v", keys)
+	}
+
+	playlist, err := p.ExpandPlaylist(7)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(playlist) != 1 || playlist[0].RatingKey != "11" {
+		t.Errorf("expected the playlist episode, got %+v", playlist)
+	}
+
+	dir, err := ioutil.TempDir("", "plex-bulk")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	show, err := p.ExpandMedia("1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifestPath := filepath.Join(dir, "manifest.json")
+
+	if _, err := p.DownloadAll(show, dir, DownloadOptions{NameTemplate: NameTemplateShow, ManifestPath: manifestPath}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(manifestPath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var manifest DownloadManifest
+
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(manifest.Files) != 2 {
+		t.Fatalf("expected 2 files in the manifest, got %+v", manifest.Files)
+	}
+
+	expected := "Breaking Bad/Season 01/Breaking Bad - S01E02 - Episode 2.mkv"
+
+	if entry := manifest.Files[1]; entry.Path != expected || entry.Status != ManifestStatusDownloaded || entry.RatingKey != "11" {
+		t.Errorf("unexpected manifest entry: %+v", entry)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(expected))); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDownloadAllEmptyManifest(t *testing.T) {
+	p, err := New("http://127.0.0.1:32400", "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "plex-bulk")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// an empty show leaves the download folder uncreated
+	path := filepath.Join(dir, "Breaking Bad")
+	manifestPath := filepath.Join(path, "manifest.json")
+
+	if _, err := p.DownloadAll(nil, path, DownloadOptions{ManifestPath: manifestPath}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(manifestPath); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,321 @@
+package plex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter operators understood by plex
+const (
+	FilterIs             = "="
+	FilterIsNot          = "!="
+	FilterGreaterThan    = ">>="
+	FilterLessThan       = "<<="
+	FilterContains       = "=" // string fields match on substrings with the = operator
+	FilterDoesNotContain = "!="
+	FilterExactly        = "=="
+	FilterNotExactly     = "!=="
+	FilterBeginsWith     = "<="
+	FilterEndsWith       = ">="
+)
+
+// fields that can always be filtered on even though they are not listed by the server's filters
+var builtinFilterFields = map[string]bool{
+	"type":         true,
+	"addedAt":      true,
+	"updatedAt":    true,
+	"lastViewedAt": true,
+}
+
+type filterTerm struct {
+	field    string
+	operator string
+	value    string
+}
+
+// Filter builds the query of GetLibraryContent. Conditions are joined with
+// "and" unless grouped with Or.
+//
+//	filter := plex.NewFilter().
+//		Type("movie").
+//		YearBetween(1990, 1999).
+//		Unwatched().
+//		Or(plex.NewFilter().Genre("Comedy"), plex.NewFilter().Genre("Drama")).
+//		Sort("addedAt", true)
+//
+//	results, err := plexConnection.GetLibraryContent("1", filter.String())
+type Filter struct {
+	terms []filterTerm
+	sorts []string
+}
+
+// NewFilter creates an empty filter
+func NewFilter() *Filter {
+	return &Filter{}
+}
+
+// Where adds a condition on any field plex can filter on, i.e. Where("studio", plex.FilterIs, "A24")
+func (f *Filter) Where(field, operator, value string) *Filter {
+	f.terms = append(f.terms, filterTerm{field: field, operator: operator, value: value})
+
+	return f
+}
+
+// Type restricts results to a media type (i.e. movie, show, episode). See GetMediaTypeID
+func (f *Filter) Type(mediaType string) *Filter {
+	return f.Where("type", FilterIs, GetMediaTypeID(mediaType))
+}
+
+// Genre restricts results to a genre title or id
+func (f *Filter) Genre(genre string) *Filter {
+	return f.Where("genre", FilterIs, genre)
+}
+
+// YearBetween restricts results to the years from and to, inclusive
+func (f *Filter) YearBetween(from, to int) *Filter {
+	f.Where("year", FilterGreaterThan, strconv.Itoa(from-1))
+
+	return f.Where("year", FilterLessThan, strconv.Itoa(to+1))
+}
+
+// Unwatched restricts results to media that has not been watched
+func (f *Filter) Unwatched() *Filter {
+	return f.Where("unwatched", FilterIs, "1")
+}
+
+// Resolution restricts results to a video resolution: 4k, 1080, 720, 480 or sd
+func (f *Filter) Resolution(resolution string) *Filter {
+	return f.Where("resolution", FilterIs, resolution)
+}
+
+// Label restricts results to media with a label
+func (f *Filter) Label(label string) *Filter {
+	return f.Where("label", FilterIs, label)
+}
+
+// ContentRating restricts results to a content rating (i.e. PG-13, TV-MA)
+func (f *Filter) ContentRating(rating string) *Filter {
+	return f.Where("contentRating", FilterIs, rating)
+}
+
+// Collection restricts results to a collection title or id
+func (f *Filter) Collection(collection string) *Filter {
+	return f.Where("collection", FilterIs, collection)
+}
+
+// AddedAfter restricts results to media added after t
+func (f *Filter) AddedAfter(t time.Time) *Filter {
+	return f.Where("addedAt", FilterGreaterThan, strconv.FormatInt(t.Unix(), 10))
+}
+
+// AddedBefore restricts results to media added before t
+func (f *Filter) AddedBefore(t time.Time) *Filter {
+	return f.Where("addedAt", FilterLessThan, strconv.FormatInt(t.Unix(), 10))
+}
+
+// AddedWithin restricts results to media added in the last days
+func (f *Filter) AddedWithin(days int) *Filter {
+	return f.Where("addedAt", FilterGreaterThan, fmt.Sprintf("-%dd", days))
+}
+
+// Sort orders results by field (i.e. titleSort, addedAt, year, rating). Calling Sort
+// more than once adds tie breakers
+func (f *Filter) Sort(field string, descending bool) *Filter {
+	if descending {
+		field += ":desc"
+	}
+
+	f.sorts = append(f.sorts, field)
+
+	return f
+}
+
+// Or adds a group where any of the filters have to match. Sorts of the filters are ignored
+func (f *Filter) Or(filters ...*Filter) *Filter {
+	return f.group("or", filters)
+}
+
+// And adds a group where all of the filters have to match. Useful inside of Or
+func (f *Filter) And(filters ...*Filter) *Filter {
+	return f.group("and", filters)
+}
+
+func (f *Filter) group(operator string, filters []*Filter) *Filter {
+	if len(filters) == 0 {
+		return f
+	}
+
+	f.terms = append(f.terms, filterTerm{field: "push", operator: FilterIs, value: "1"})
+
+	for i, filter := range filters {
+		if i > 0 && operator == "or" {
+			f.terms = append(f.terms, filterTerm{field: "or", operator: FilterIs, value: "1"})
+		}
+
+		f.terms = append(f.terms, filter.terms...)
+	}
+
+	f.terms = append(f.terms, filterTerm{field: "pop", operator: FilterIs, value: "1"})
+
+	return f
+}
+
+// Query renders the filter without a leading question mark
+func (f *Filter) Query() string {
+	params := make([]string, 0, len(f.terms)+1)
+
+	for _, term := range f.terms {
+		// the operator without the trailing equal sign is part of the key
+		key := term.field + strings.TrimSuffix(term.operator, "=")
+
+		params = append(params, url.QueryEscape(key)+"="+url.QueryEscape(term.value))
+	}
+
+	if len(f.sorts) > 0 {
+		params = append(params, "sort="+url.QueryEscape(strings.Join(f.sorts, ",")))
+	}
+
+	return strings.Join(params, "&")
+}
+
+// String renders the filter as the query string suffix expected by GetLibraryContent
+func (f *Filter) String() string {
+	query := f.Query()
+
+	if query == "" {
+		return ""
+	}
+
+	return "?" + query
+}
+
+// Validate checks that every field and sort of the filter is supported by the section
+func (f *Filter) Validate(filters []LibraryFilter, sorts []LibrarySort) error {
+	availableFilters := make(map[string]bool, len(filters))
+
+	for _, filter := range filters {
+		availableFilters[filter.Filter] = true
+	}
+
+	for _, term := range f.terms {
+		switch term.field {
+		case "push", "pop", "or":
+			continue
+		}
+
+		if !builtinFilterFields[term.field] && !availableFilters[term.field] {
+			return fmt.Errorf("section can not be filtered by %s", term.field)
+		}
+	}
+
+	availableSorts := make(map[string]bool, len(sorts))
+
+	for _, sort := range sorts {
+		availableSorts[sort.Key] = true
+	}
+
+	for _, sort := range f.sorts {
+		field := strings.TrimSuffix(sort, ":desc")
+
+		if !availableSorts[field] {
+			return fmt.Errorf("section can not be sorted by %s", field)
+		}
+	}
+
+	return nil
+}
+
+// LibraryFilter is a field a library section can be filtered on
+type LibraryFilter struct {
+	Filter     string `json:"filter"`
+	FilterType string `json:"filterType"`
+	Key        string `json:"key"`
+	Title      string `json:"title"`
+	Type       string `json:"type"`
+}
+
+// LibrarySort is a field a library section can be sorted by
+type LibrarySort struct {
+	Default          string `json:"default"`
+	DefaultDirection string `json:"defaultDirection"`
+	DescKey          string `json:"descKey"`
+	FirstCharacter   string `json:"firstCharacterKey"`
+	Key              string `json:"key"`
+	Title            string `json:"title"`
+}
+
+// GetLibraryFilters returns the fields a library section can be filtered on
+func (p *Plex) GetLibraryFilters(sectionKey string) ([]LibraryFilter, error) {
+	return p.GetLibraryFiltersContext(context.Background(), sectionKey)
+}
+
+// GetLibraryFiltersContext is like GetLibraryFilters but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetLibraryFiltersContext(ctx context.Context, sectionKey string) ([]LibraryFilter, error) {
+	var result struct {
+		MediaContainer struct {
+			Directory []LibraryFilter `json:"Directory"`
+		} `json:"MediaContainer"`
+	}
+
+	query := fmt.Sprintf("%s/library/sections/%s/filters", p.URL, sectionKey)
+
+	if err := p.requestJSON(ctx, http.MethodGet, query, &result); err != nil {
+		return []LibraryFilter{}, err
+	}
+
+	return result.MediaContainer.Directory, nil
+}
+
+// GetLibrarySorts returns the fields a library section can be sorted by
+func (p *Plex) GetLibrarySorts(sectionKey string) ([]LibrarySort, error) {
+	return p.GetLibrarySortsContext(context.Background(), sectionKey)
+}
+
+// GetLibrarySortsContext is like GetLibrarySorts but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetLibrarySortsContext(ctx context.Context, sectionKey string) ([]LibrarySort, error) {
+	var result struct {
+		MediaContainer struct {
+			Directory []LibrarySort `json:"Directory"`
+		} `json:"MediaContainer"`
+	}
+
+	query := fmt.Sprintf("%s/library/sections/%s/sorts", p.URL, sectionKey)
+
+	if err := p.requestJSON(ctx, http.MethodGet, query, &result); err != nil {
+		return []LibrarySort{}, err
+	}
+
+	return result.MediaContainer.Directory, nil
+}
+
+// FilterLibraryContent validates the filter against the section's filters and sorts
+// before retrieving the matching content
+func (p *Plex) FilterLibraryContent(sectionKey string, filter *Filter) (SearchResults, error) {
+	return p.FilterLibraryContentContext(context.Background(), sectionKey, filter)
+}
+
+// FilterLibraryContentContext is like FilterLibraryContent but uses ctx to cancel the requests or bound their duration
+func (p *Plex) FilterLibraryContentContext(ctx context.Context, sectionKey string, filter *Filter) (SearchResults, error) {
+	filters, err := p.GetLibraryFiltersContext(ctx, sectionKey)
+
+	if err != nil {
+		return SearchResults{}, err
+	}
+
+	sorts, err := p.GetLibrarySortsContext(ctx, sectionKey)
+
+	if err != nil {
+		return SearchResults{}, err
+	}
+
+	if err := filter.Validate(filters, sorts); err != nil {
+		return SearchResults{}, err
+	}
+
+	return p.GetLibraryContentContext(ctx, sectionKey, filter.String())
+}
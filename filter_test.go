@@ -0,0 +1,115 @@
+package plex
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestFilterQuery(t *testing.T) {
+	filter := NewFilter().
+		Type("movie").
+		YearBetween(1990, 1999).
+		Unwatched().
+		Or(NewFilter().Genre("Comedy"), NewFilter().Genre("Drama")).
+		Sort("addedAt", true).
+		Sort("titleSort", false)
+
+	values, err := url.ParseQuery(filter.Query())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"type":      "1",
+		"year>>":    "1989",
+		"year<<":    "2000",
+		"unwatched": "1",
+		"push":      "1",
+		"or":        "1",
+		"pop":       "1",
+		"sort":      "addedAt:desc,titleSort",
+	}
+
+	for key, value := range expected {
+		if got := values.Get(key); got != value {
+			t.Errorf("expected %s to be %q, got %q", key, value, got)
+		}
+	}
+
+	if genres := values["genre"]; len(genres) != 2 || genres[0] != "Comedy" || genres[1] != "Drama" {
+		t.Errorf("expected both genres in order, got %v", genres)
+	}
+
+	expectedQuery := "?type=1&year%3E%3E=1989&year%3C%3C=2000&unwatched=1&push=1&genre=Comedy&or=1&genre=Drama&pop=1&sort=addedAt%3Adesc%2CtitleSort"
+
+	if filter.String() != expectedQuery {
+		t.Errorf("expected %s, got %s", expectedQuery, filter.String())
+	}
+
+	if NewFilter().String() != "" {
+		t.Errorf("expected an empty filter to render nothing, got %s", NewFilter().String())
+	}
+}
+
+func TestFilterValidate(t *testing.T) {
+	filters := []LibraryFilter{{Filter: "genre"}, {Filter: "unwatched"}}
+	sorts := []LibrarySort{{Key: "titleSort"}}
+
+	if err := NewFilter().Type("movie").Genre("Drama").Sort("titleSort", true).Validate(filters, sorts); err != nil {
+		t.Errorf("expected filter to be valid: %v", err)
+	}
+
+	if err := NewFilter().Resolution("4k").Validate(filters, sorts); err == nil {
+		t.Error("expected an error for an unsupported filter")
+	}
+
+	if err := NewFilter().Sort("rating", false).Validate(filters, sorts); err == nil {
+		t.Error("expected an error for an unsupported sort")
+	}
+}
+
+func TestFilterLibraryContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", applicationJson)
+
+		switch r.URL.Path {
+		case "/library/sections/1/filters":
+			fmt.Fprint(w, `{"MediaContainer":{"Directory":[{"filter":"genre","filterType":"string","key":"/library/sections/1/genre","title":"Genre","type":"filter"}]}}`)
+		case "/library/sections/1/sorts":
+			fmt.Fprint(w, `{"MediaContainer":{"Directory":[{"default":"asc","descKey":"titleSort:desc","key":"titleSort","title":"Title"}]}}`)
+		case "/library/sections/1/all":
+			if r.URL.Query().Get("genre") != "Drama" {
+				t.Errorf("expected genre filter, got %s", r.URL.RawQuery)
+			}
+
+			fmt.Fprint(w, `{"MediaContainer":{"size":1,"Metadata":[{"title":"Heat"}]}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := p.FilterLibraryContent("1", NewFilter().Genre("Drama").Sort("titleSort", false))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results.MediaContainer.Metadata) != 1 || results.MediaContainer.Metadata[0].Title != "Heat" {
+		t.Errorf("unexpected results: %+v", results.MediaContainer.Metadata)
+	}
+
+	if _, err := p.FilterLibraryContent("1", NewFilter().Label("4K")); err == nil {
+		t.Error("expected unsupported label filter to fail validation")
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/jrudio/go-plex-client
 
-go 1.12
+go 1.13
 
 require (
 	github.com/dgraph-io/badger/v3 v3.2103.2
@@ -0,0 +1,90 @@
+package plex
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Statuses of a file in a DownloadManifest
+const (
+	ManifestStatusDownloaded = "downloaded"
+	ManifestStatusResumed    = "resumed"
+	ManifestStatusSkipped    = "skipped"
+	ManifestStatusFailed     = "failed"
+)
+
+// DownloadManifest records the files of a download
+type DownloadManifest struct {
+	CreatedAt time.Time       `json:"createdAt"`
+	Files     []ManifestEntry `json:"files"`
+}
+
+// ManifestEntry is a file of a DownloadManifest
+type ManifestEntry struct {
+	RatingKey string `json:"ratingKey"`
+	Title     string `json:"title"`
+	Type      string `json:"type"`
+	PartKey   string `json:"partKey"`
+	// Path is relative to the download folder
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// NewDownloadManifest creates the manifest of the results of DownloadAll. dir is the download folder
+func NewDownloadManifest(dir string, results []DownloadResult) DownloadManifest {
+	manifest := DownloadManifest{
+		CreatedAt: time.Now(),
+		Files:     make([]ManifestEntry, len(results)),
+	}
+
+	for i, result := range results {
+		entry := ManifestEntry{
+			RatingKey: result.Item.RatingKey,
+			Title:     result.Item.Title,
+			Type:      result.Item.Type,
+			PartKey:   result.Part.Key,
+			Path:      result.Path,
+			Size:      result.Size,
+			Status:    ManifestStatusDownloaded,
+		}
+
+		if rel, err := filepath.Rel(dir, result.Path); err == nil {
+			entry.Path = filepath.ToSlash(rel)
+		}
+
+		switch {
+		case result.Err != nil:
+			entry.Status = ManifestStatusFailed
+			entry.Error = result.Err.Error()
+		case result.Skipped:
+			entry.Status = ManifestStatusSkipped
+		case result.Resumed:
+			entry.Status = ManifestStatusResumed
+		}
+
+		manifest.Files[i] = entry
+	}
+
+	return manifest
+}
+
+// Save writes the manifest to path as json, creating its folder if needed
+func (m DownloadManifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	// nothing else creates the folder when there was nothing to download
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
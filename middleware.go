@@ -0,0 +1,38 @@
+package plex
+
+import "net/http"
+
+// RequestHandler sends a request to a Plex Media Server or plex.tv and returns its response
+type RequestHandler func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps a RequestHandler to inspect or modify requests and responses
+// (i.e. logging, metrics, header injection, auth refresh or fault injection).
+// A middleware may skip calling next to short-circuit the request
+type Middleware func(next RequestHandler) RequestHandler
+
+// Use appends middleware to the request pipeline. Every request made by this
+// client goes through the middleware in the order they were added; the first
+// middleware sees the request first and the response last
+func (p *Plex) Use(middleware ...Middleware) {
+	p.Middleware = append(p.Middleware, middleware...)
+}
+
+// chain wraps handler with the client's middleware
+func (p *Plex) chain(handler RequestHandler) RequestHandler {
+	for i := len(p.Middleware) - 1; i >= 0; i-- {
+		handler = p.Middleware[i](handler)
+	}
+
+	return handler
+}
+
+// HeaderMiddleware sets a header on every request
+func HeaderMiddleware(key, value string) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set(key, value)
+
+			return next(req)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package plex
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	var receivedHeader string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedHeader = r.Header.Get("X-Test")
+		w.Write([]byte(`{"MediaContainer":{"size":0}}`))
+	}))
+	defer server.Close()
+
+	var calls []string
+
+	trace := func(name string) Middleware {
+		return func(next RequestHandler) RequestHandler {
+			return func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" before")
+				resp, err := next(req)
+				calls = append(calls, name+" after")
+
+				return resp, err
+			}
+		}
+	}
+
+	p, err := New(server.URL, "abc123", WithMiddleware(trace("first"), HeaderMiddleware("X-Test", "injected")))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Use(trace("second"))
+
+	if _, err := p.GetSessions(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"first before", "second before", "second after", "first after"}
+
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected %v, got %v", expected, calls)
+	}
+
+	if receivedHeader != "injected" {
+		t.Errorf("expected injected header, got %q", receivedHeader)
+	}
+}
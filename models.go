@@ -17,6 +17,13 @@ type Plex struct {
 	Headers          headers
 	HTTPClient       http.Client
 	DownloadClient   http.Client
+	// PlexTVURL overrides the plex.tv endpoint (https://plex.tv) used for account
+	// level requests, i.e. to point at a test server or a proxy
+	PlexTVURL string
+	// Middleware is the ordered chain every request goes through. See Use
+	Middleware []Middleware
+	// Retry is an optional policy to retry idempotent requests
+	Retry *RetryPolicy
 }
 
 // SearchResults a list of media returned when searching
@@ -69,6 +76,8 @@ type Metadata struct {
 	ParentRatingKey       string       `json:"parentRatingKey"`
 	ParentThumb           string       `json:"parentThumb"`
 	ParentTitle           string       `json:"parentTitle"`
+	PlaylistItemID        int          `json:"playlistItemID"`
+	PlayQueueItemID       int          `json:"playQueueItemID"`
 	RatingCount           int          `json:"ratingCount"`
 	Rating                float64      `json:"rating"`
 	RatingKey             string       `json:"ratingKey"`
@@ -86,6 +95,8 @@ type Metadata struct {
 	Year                  int          `json:"year"`
 	Director              []TaggedData `json:"Director"`
 	Writer                []TaggedData `json:"Writer"`
+	// TranscodeSession is only set for sessions that are transcoded
+	TranscodeSession TranscodeSession `json:"TranscodeSession"`
 }
 
 // AltGUID represents a Globally Unique Identifier for a metadata provider that is not actively being used.
@@ -113,6 +124,15 @@ func (b *boolOrInt) UnmarshalJSON(data []byte) error {
 		}
 	}
 
+	// some endpoints quote the value, i.e. "1"
+	var isString string
+
+	if err := json.Unmarshal(data, &isString); err == nil {
+		b.bool = isString == "1" || isString == "true"
+
+		return nil
+	}
+
 	var isBool bool
 
 	if err := json.Unmarshal(data, &isBool); err != nil {
@@ -124,6 +144,11 @@ func (b *boolOrInt) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Bool returns the decoded value
+func (b boolOrInt) Bool() bool {
+	return b.bool
+}
+
 // Media media info
 type Media struct {
 	AspectRatio           json.Number `json:"aspectRatio"`
@@ -158,6 +183,9 @@ type MediaContainer struct {
 	MediaTagPrefix      string     `json:"mediaTagPrefix"`
 	MediaTagVersion     int        `json:"mediaTagVersion"`
 	Size                int        `json:"size"`
+	// Offset and TotalSize are set when requesting a page of results
+	Offset    int `json:"offset"`
+	TotalSize int `json:"totalSize"`
 }
 
 // MediaMetadata ...
@@ -784,6 +812,9 @@ type headers struct {
 	ContentType            string
 	ClientIdentifier       string
 	TargetClientIdentifier string
+	Range                  string
+	// NoRetry sends the request once even with a RetryPolicy, for requests that are not safe to repeat
+	NoRetry bool
 }
 
 type request struct {
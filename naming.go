@@ -0,0 +1,90 @@
+package plex
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Naming templates for DownloadOptions.NameTemplate
+const (
+	NameTemplateShow  = "{show}/Season {season:02}/{show} - S{season:02}E{episode:02} - {title}.{ext}"
+	NameTemplateMovie = "{title} ({year})/{title} ({year}).{ext}"
+	NameTemplateMusic = "{artist}/{album}/{track:02} - {title}.{ext}"
+)
+
+var namePlaceholder = regexp.MustCompile(`\{(\w+)(?::(\d+))?\}`)
+
+// unsafeNameCharacters are replaced in the values of a template so they can not create folders
+var unsafeNameCharacters = strings.NewReplacer("/", "_", `\`, "_", ":", "_", "*", "_", "?", "_", `"`, "_", "<", "_", ">", "_", "|", "_")
+
+// RenderName renders the path of a part of meta from template, a slash separated path with
+// placeholders in braces. Numbers can be zero padded, i.e. {season:02}. The placeholders are
+// title, show, season, episode, artist, album, track, year, type, ratingKey, library,
+// filename (the original name without extension), ext and part (the index of the part)
+func RenderName(template string, meta Metadata, part Part, partIndex int) (string, error) {
+	filename := partFilename(part)
+	ext := strings.TrimPrefix(filepath.Ext(filename), ".")
+
+	if ext == "" {
+		ext = part.Container
+	}
+
+	values := map[string]interface{}{
+		"title":     meta.Title,
+		"show":      meta.GrandparentTitle,
+		"season":    int(meta.ParentIndex),
+		"episode":   int(meta.Index),
+		"artist":    meta.GrandparentTitle,
+		"album":     meta.ParentTitle,
+		"track":     int(meta.Index),
+		"year":      meta.Year,
+		"type":      meta.Type,
+		"ratingKey": meta.RatingKey,
+		"library":   meta.LibrarySectionTitle,
+		"filename":  strings.TrimSuffix(filename, filepath.Ext(filename)),
+		"ext":       ext,
+		"part":      partIndex,
+	}
+
+	var err error
+
+	name := namePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		match := namePlaceholder.FindStringSubmatch(placeholder)
+
+		value, ok := values[match[1]]
+
+		if !ok {
+			err = fmt.Errorf("unknown placeholder %s in name template", placeholder)
+			return placeholder
+		}
+
+		switch v := value.(type) {
+		case int:
+			if match[2] == "" {
+				return strconv.Itoa(v)
+			}
+
+			width, _ := strconv.Atoi(match[2])
+
+			return fmt.Sprintf("%0*d", width, v)
+		default:
+			name := strings.TrimSpace(unsafeNameCharacters.Replace(v.(string)))
+
+			// a title can not point at a parent folder
+			if name == "." || name == ".." {
+				return "_"
+			}
+
+			return name
+		}
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.FromSlash(name), nil
+}
@@ -0,0 +1,60 @@
+package plex
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderName(t *testing.T) {
+	episode := Metadata{
+		Title:            "Cat's in the Bag...",
+		GrandparentTitle: "Breaking Bad",
+		ParentTitle:      "Season 1",
+		ParentIndex:      1,
+		Index:            2,
+		Year:             2008,
+		Type:             "episode",
+	}
+
+	track := Metadata{
+		Title:            "Paranoid Android",
+		GrandparentTitle: "Radiohead",
+		ParentTitle:      "OK Computer",
+		Index:            2,
+		Type:             "track",
+	}
+
+	movie := Metadata{
+		Title: "Face/Off",
+		Year:  1997,
+		Type:  "movie",
+	}
+
+	testCases := []struct {
+		template string
+		meta     Metadata
+		part     Part
+		expected string
+	}{
+		{NameTemplateShow, episode, Part{File: "/tv/S01E02.mkv"}, "Breaking Bad/Season 01/Breaking Bad - S01E02 - Cat's in the Bag....mkv"},
+		{NameTemplateMusic, track, Part{File: `C:\music\02.flac`}, "Radiohead/OK Computer/02 - Paranoid Android.flac"},
+		{NameTemplateMovie, movie, Part{File: "/movies/faceoff", Container: "mp4"}, "Face_Off (1997)/Face_Off (1997).mp4"},
+		{"{filename}.{ext}", movie, Part{File: "/movies/faceoff.avi"}, "faceoff.avi"},
+	}
+
+	for _, tc := range testCases {
+		name, err := RenderName(tc.template, tc.meta, tc.part, 1)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := filepath.FromSlash(tc.expected); name != expected {
+			t.Errorf("expected %s, got %s", expected, name)
+		}
+	}
+
+	if _, err := RenderName("{director}.{ext}", movie, Part{}, 1); err == nil {
+		t.Error("expected an error for an unknown placeholder")
+	}
+}
@@ -0,0 +1,56 @@
+package plex
+
+import (
+	"net/http"
+	"time"
+)
+
+// defaultTimeout is the timeout of HTTPClient unless changed via an Option
+const defaultTimeout = 3 * time.Second
+
+// Option configures a plex client created by New or SignIn
+type Option func(p *Plex)
+
+// WithHTTPClient replaces the client used for api requests
+func WithHTTPClient(client http.Client) Option {
+	return func(p *Plex) {
+		p.HTTPClient = client
+	}
+}
+
+// WithDownloadClient replaces the client used to download media
+func WithDownloadClient(client http.Client) Option {
+	return func(p *Plex) {
+		p.DownloadClient = client
+	}
+}
+
+// WithTimeout sets the timeout of api requests. Downloads are not affected
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Plex) {
+		p.HTTPClient.Timeout = timeout
+	}
+}
+
+// WithTransport sets a custom round-tripper on both the api and download clients
+func WithTransport(transport http.RoundTripper) Option {
+	return func(p *Plex) {
+		p.HTTPClient.Transport = transport
+		p.DownloadClient.Transport = transport
+	}
+}
+
+// WithMiddleware appends middleware to the request pipeline. See Plex.Use
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(p *Plex) {
+		p.Use(middleware...)
+	}
+}
+
+// WithPlexTVURL points account level requests (friends, pins, webhooks, etc)
+// at baseURL instead of https://plex.tv
+func WithPlexTVURL(baseURL string) Option {
+	return func(p *Plex) {
+		p.PlexTVURL = baseURL
+	}
+}
@@ -0,0 +1,180 @@
+package plex
+
+import (
+	"context"
+	"strconv"
+)
+
+// defaultPageSize is used by the iterators when no page size is given
+const defaultPageSize = 50
+
+// pageHeaders returns a copy of the client's headers requesting a page of results
+func (p *Plex) pageHeaders(start, size int) headers {
+	h := p.Headers
+
+	h.ContainerStart = strconv.Itoa(start)
+	h.ContainerSize = strconv.Itoa(size)
+
+	return h
+}
+
+// GetLibraryContentPage retrieves up to size items inside a library starting at offset start.
+// MediaContainer.TotalSize holds the number of items in the whole library
+func (p *Plex) GetLibraryContentPage(sectionKey, filter string, start, size int) (SearchResults, error) {
+	return p.GetLibraryContentPageContext(context.Background(), sectionKey, filter, start, size)
+}
+
+// GetLibraryContentPageContext is like GetLibraryContentPage but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetLibraryContentPageContext(ctx context.Context, sectionKey, filter string, start, size int) (SearchResults, error) {
+	return p.getLibraryContent(ctx, sectionKey, filter, p.pageHeaders(start, size))
+}
+
+// SearchPage searches your Plex Server for media returning up to size results starting at offset start
+func (p *Plex) SearchPage(title string, start, size int) (SearchResults, error) {
+	return p.SearchPageContext(context.Background(), title, start, size)
+}
+
+// SearchPageContext is like SearchPage but uses ctx to cancel the request or bound its duration
+func (p *Plex) SearchPageContext(ctx context.Context, title string, start, size int) (SearchResults, error) {
+	return p.search(ctx, title, p.pageHeaders(start, size))
+}
+
+// GetPlaylistPage gets up to size items in a playlist starting at offset start
+func (p *Plex) GetPlaylistPage(key, start, size int) (SearchResultsEpisode, error) {
+	return p.GetPlaylistPageContext(context.Background(), key, start, size)
+}
+
+// GetPlaylistPageContext is like GetPlaylistPage but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetPlaylistPageContext(ctx context.Context, key, start, size int) (SearchResultsEpisode, error) {
+	return p.getPlaylist(ctx, key, p.pageHeaders(start, size))
+}
+
+// pageFetcher requests a page of results
+type pageFetcher func(ctx context.Context, start, size int) (MediaContainer, error)
+
+// MetadataIterator lazily walks every page of a library section, search or playlist.
+//
+//	items := plexConnection.LibraryContentIterator(ctx, "1", "", 100)
+//
+//	for items.Next() {
+//		fmt.Println(items.Metadata().Title)
+//	}
+//
+//	if err := items.Err(); err != nil {
+//		// handle error
+//	}
+type MetadataIterator struct {
+	ctx      context.Context
+	fetch    pageFetcher
+	pageSize int
+
+	start     int
+	totalSize int
+	page      []Metadata
+	index     int
+	done      bool
+	err       error
+}
+
+func newMetadataIterator(ctx context.Context, pageSize int, fetch pageFetcher) *MetadataIterator {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	return &MetadataIterator{
+		ctx:      ctx,
+		fetch:    fetch,
+		pageSize: pageSize,
+		index:    -1,
+	}
+}
+
+// Next advances to the next item, fetching the next page when needed. It returns
+// false when there are no more items or an error occurred
+func (it *MetadataIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.index+1 < len(it.page) {
+		it.index++
+		return true
+	}
+
+	if it.done {
+		return false
+	}
+
+	container, err := it.fetch(it.ctx, it.start, it.pageSize)
+
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	// a server that ignores pagination answers a later page with the first one again,
+	// which was already returned
+	if it.start > 0 && container.Offset != it.start {
+		it.page = nil
+		it.done = true
+		return false
+	}
+
+	it.page = container.Metadata
+	it.index = 0
+	it.start += len(container.Metadata)
+	it.totalSize = container.TotalSize
+
+	// servers that ignore pagination return everything in one go
+	if len(it.page) != it.pageSize || (it.totalSize > 0 && it.start >= it.totalSize) {
+		it.done = true
+	}
+
+	return len(it.page) > 0
+}
+
+// Metadata returns the current item
+func (it *MetadataIterator) Metadata() Metadata {
+	if it.index < 0 || it.index >= len(it.page) {
+		return Metadata{}
+	}
+
+	return it.page[it.index]
+}
+
+// TotalSize is the number of items reported by the server once the first page was fetched
+func (it *MetadataIterator) TotalSize() int {
+	return it.totalSize
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *MetadataIterator) Err() error {
+	return it.err
+}
+
+// LibraryContentIterator walks the content of a library section pageSize items at a time
+func (p *Plex) LibraryContentIterator(ctx context.Context, sectionKey, filter string, pageSize int) *MetadataIterator {
+	return newMetadataIterator(ctx, pageSize, func(ctx context.Context, start, size int) (MediaContainer, error) {
+		results, err := p.GetLibraryContentPageContext(ctx, sectionKey, filter, start, size)
+
+		return results.MediaContainer.MediaContainer, err
+	})
+}
+
+// SearchIterator walks the search results of title pageSize items at a time
+func (p *Plex) SearchIterator(ctx context.Context, title string, pageSize int) *MetadataIterator {
+	return newMetadataIterator(ctx, pageSize, func(ctx context.Context, start, size int) (MediaContainer, error) {
+		results, err := p.SearchPageContext(ctx, title, start, size)
+
+		return results.MediaContainer.MediaContainer, err
+	})
+}
+
+// PlaylistIterator walks the items of a playlist pageSize items at a time
+func (p *Plex) PlaylistIterator(ctx context.Context, key, pageSize int) *MetadataIterator {
+	return newMetadataIterator(ctx, pageSize, func(ctx context.Context, start, size int) (MediaContainer, error) {
+		results, err := p.GetPlaylistPageContext(ctx, key, start, size)
+
+		return results.MediaContainer, err
+	})
+}
@@ -0,0 +1,170 @@
+package plex
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func newPagedLibraryServer(t *testing.T, titles []string, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+
+		if r.URL.Path != "/library/sections/1/all" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		start, _ := strconv.Atoi(r.Header.Get("X-Plex-Container-Start"))
+		size, err := strconv.Atoi(r.Header.Get("X-Plex-Container-Size"))
+
+		if err != nil {
+			t.Errorf("missing container size header: %v", err)
+		}
+
+		end := start + size
+
+		if end > len(titles) {
+			end = len(titles)
+		}
+
+		var results SearchResults
+
+		results.MediaContainer.Offset = start
+		results.MediaContainer.TotalSize = len(titles)
+
+		for _, title := range titles[start:end] {
+			results.MediaContainer.Metadata = append(results.MediaContainer.Metadata, Metadata{Title: title})
+		}
+
+		results.MediaContainer.Size = len(results.MediaContainer.Metadata)
+
+		json.NewEncoder(w).Encode(results)
+	}))
+}
+
+func TestGetLibraryContentPage(t *testing.T) {
+	requests := 0
+	server := newPagedLibraryServer(t, []string{"a", "b", "c", "d", "e"}, &requests)
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := p.GetLibraryContentPage("1", "", 2, 2)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	container := results.MediaContainer
+
+	if container.TotalSize != 5 || container.Offset != 2 || len(container.Metadata) != 2 || container.Metadata[0].Title != "c" {
+		t.Errorf("unexpected page: %+v", container.MediaContainer)
+	}
+}
+
+func TestLibraryContentIterator(t *testing.T) {
+	requests := 0
+	titles := []string{"a", "b", "c", "d", "e"}
+	server := newPagedLibraryServer(t, titles, &requests)
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := p.LibraryContentIterator(context.Background(), "1", "", 2)
+
+	var walked []string
+
+	for items.Next() {
+		walked = append(walked, items.Metadata().Title)
+	}
+
+	if err := items.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(walked) != len(titles) {
+		t.Fatalf("expected %v, got %v", titles, walked)
+	}
+
+	for i := range titles {
+		if walked[i] != titles[i] {
+			t.Errorf("expected %s at %d, got %s", titles[i], i, walked[i])
+		}
+	}
+
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+
+	if items.TotalSize() != 5 {
+		t.Errorf("expected a total size of 5, got %d", items.TotalSize())
+	}
+}
+
+func TestLibraryContentIteratorIgnoredPagination(t *testing.T) {
+	for _, titles := range [][]string{{"a", "b", "c", "d", "e"}, {"a", "b"}} {
+		requests := 0
+
+		// returns the whole library whatever the requested page and without a total size
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+
+			var results SearchResults
+
+			for _, title := range titles {
+				results.MediaContainer.Metadata = append(results.MediaContainer.Metadata, Metadata{Title: title})
+			}
+
+			results.MediaContainer.Size = len(results.MediaContainer.Metadata)
+
+			json.NewEncoder(w).Encode(results)
+		}))
+
+		p, err := New(server.URL, "abc123")
+
+		if err != nil {
+			server.Close()
+			t.Fatal(err)
+		}
+
+		items := p.LibraryContentIterator(context.Background(), "1", "", 2)
+
+		var walked []string
+
+		for items.Next() && len(walked) <= 2*len(titles) {
+			walked = append(walked, items.Metadata().Title)
+		}
+
+		server.Close()
+
+		if err := items.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(walked) != len(titles) {
+			t.Fatalf("expected %v, got %v", titles, walked)
+		}
+
+		for i := range titles {
+			if walked[i] != titles[i] {
+				t.Errorf("expected %s at %d, got %s", titles[i], i, walked[i])
+			}
+		}
+
+		if requests > 2 {
+			t.Errorf("expected at most 2 requests, got %d", requests)
+		}
+	}
+}
@@ -0,0 +1,590 @@
+package plex
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// timelineSubscriptionRenewal is how often SubscribeTimeline renews its subscription
+	timelineSubscriptionRenewal = time.Minute
+	// unsubscribeTimeout bounds the unsubscribe sent once the context of SubscribeTimeline is done
+	unsubscribeTimeout = 5 * time.Second
+	// maxTimelineSize is the largest timeline a player may post
+	maxTimelineSize = 1 << 20
+)
+
+// Player media types a command applies to
+const (
+	PlayerTypeVideo = "video"
+	PlayerTypeMusic = "music"
+	PlayerTypePhoto = "photo"
+)
+
+// Repeat modes of SetRepeat
+const (
+	RepeatOff = 0
+	RepeatOne = 1
+	RepeatAll = 2
+)
+
+// Navigation commands of Navigate
+const (
+	NavigationMoveUp      = "moveUp"
+	NavigationMoveDown    = "moveDown"
+	NavigationMoveLeft    = "moveLeft"
+	NavigationMoveRight   = "moveRight"
+	NavigationPageUp      = "pageUp"
+	NavigationPageDown    = "pageDown"
+	NavigationSelect      = "select"
+	NavigationBack        = "back"
+	NavigationHome        = "home"
+	NavigationContextMenu = "contextMenu"
+	NavigationToggleOSD   = "toggleOSD"
+)
+
+// PlayerClient is a player connected to your plex server
+type PlayerClient struct {
+	Address              string `json:"address"`
+	DeviceClass          string `json:"deviceClass"`
+	Host                 string `json:"host"`
+	MachineIdentifier    string `json:"machineIdentifier"`
+	Name                 string `json:"name"`
+	Port                 int    `json:"port"`
+	Product              string `json:"product"`
+	Protocol             string `json:"protocol"`
+	ProtocolCapabilities string `json:"protocolCapabilities"`
+	ProtocolVersion      string `json:"protocolVersion"`
+	Version              string `json:"version"`
+}
+
+// PlayerTimeline is the playback state of a player
+type PlayerTimeline struct {
+	XMLName   xml.Name   `xml:"MediaContainer"`
+	CommandID int        `xml:"commandID,attr"`
+	Location  string     `xml:"location,attr"`
+	Timeline  []Timeline `xml:"Timeline"`
+}
+
+// Timeline is the playback state of a player for one media type
+type Timeline struct {
+	AudioStreamID     string `xml:"audioStreamID,attr"`
+	ContainerKey      string `xml:"containerKey,attr"`
+	Controllable      string `xml:"controllable,attr"`
+	Duration          int    `xml:"duration,attr"`
+	Key               string `xml:"key,attr"`
+	MachineIdentifier string `xml:"machineIdentifier,attr"`
+	PlayQueueID       int    `xml:"playQueueID,attr"`
+	PlayQueueItemID   int    `xml:"playQueueItemID,attr"`
+	PlayQueueVersion  int    `xml:"playQueueVersion,attr"`
+	RatingKey         string `xml:"ratingKey,attr"`
+	Repeat            int    `xml:"repeat,attr"`
+	Shuffle           int    `xml:"shuffle,attr"`
+	State             string `xml:"state,attr"`
+	SubtitleStreamID  string `xml:"subtitleStreamID,attr"`
+	Time              int    `xml:"time,attr"`
+	Type              string `xml:"type,attr"`
+	Volume            int    `xml:"volume,attr"`
+}
+
+// Active returns the timeline of the media type that is playing or paused, if any
+func (t PlayerTimeline) Active() (Timeline, bool) {
+	for _, timeline := range t.Timeline {
+		if timeline.State == "playing" || timeline.State == "paused" || timeline.State == "buffering" {
+			return timeline, true
+		}
+	}
+
+	return Timeline{}, false
+}
+
+// GetClients returns the players connected to your plex server
+func (p *Plex) GetClients() ([]PlayerClient, error) {
+	return p.GetClientsContext(context.Background())
+}
+
+// GetClientsContext is like GetClients but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetClientsContext(ctx context.Context) ([]PlayerClient, error) {
+	var result struct {
+		MediaContainer struct {
+			Server []PlayerClient `json:"Server"`
+		} `json:"MediaContainer"`
+	}
+
+	if err := p.requestJSON(ctx, http.MethodGet, p.URL+"/clients", &result); err != nil {
+		return []PlayerClient{}, err
+	}
+
+	return result.MediaContainer.Server, nil
+}
+
+// PlayerController remotely controls a player through your plex server. Every command
+// carries an incrementing command id as required by the companion protocol
+type PlayerController struct {
+	// MachineID is the client identifier of the player
+	MachineID string
+	// MediaType is the media type playback commands apply to. Defaults to video
+	MediaType string
+
+	plex      *Plex
+	mu        sync.Mutex
+	commandID int
+}
+
+// NewPlayerController creates a controller for the player machineID
+func (p *Plex) NewPlayerController(machineID string) *PlayerController {
+	return &PlayerController{
+		MachineID: machineID,
+		MediaType: PlayerTypeVideo,
+		plex:      p,
+	}
+}
+
+// CommandID returns the id of the last command sent
+func (c *PlayerController) CommandID() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.commandID
+}
+
+func (c *PlayerController) nextCommandID() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.commandID++
+
+	return c.commandID
+}
+
+// send issues a request to the player at path, proxied by the server. Commands are not retried
+// as the player could run them twice, i.e. skip two tracks
+func (c *PlayerController) send(ctx context.Context, path string, params url.Values, retry bool) (*http.Response, error) {
+	if params == nil {
+		params = url.Values{}
+	}
+
+	params.Set("commandID", strconv.Itoa(c.nextCommandID()))
+
+	h := c.plex.Headers
+
+	h.Accept = applicationXml
+	h.TargetClientIdentifier = c.MachineID
+	h.NoRetry = !retry
+
+	return c.plex.get(ctx, fmt.Sprintf("%s%s?%s", c.plex.URL, path, params.Encode()), h)
+}
+
+func (c *PlayerController) command(ctx context.Context, path string, params url.Values) error {
+	resp, err := c.send(ctx, path, params, false)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp)
+	}
+
+	return nil
+}
+
+func (c *PlayerController) playback(ctx context.Context, command string, params url.Values) error {
+	if params == nil {
+		params = url.Values{}
+	}
+
+	mediaType := c.MediaType
+
+	if mediaType == "" {
+		mediaType = PlayerTypeVideo
+	}
+
+	params.Set("type", mediaType)
+
+	return c.command(ctx, "/player/playback/"+command, params)
+}
+
+// Play resumes playback
+func (c *PlayerController) Play() error {
+	return c.PlayContext(context.Background())
+}
+
+// PlayContext is like Play but uses ctx to cancel the request or bound its duration
+func (c *PlayerController) PlayContext(ctx context.Context) error {
+	return c.playback(ctx, "play", nil)
+}
+
+// Pause pauses playback
+func (c *PlayerController) Pause() error {
+	return c.PauseContext(context.Background())
+}
+
+// PauseContext is like Pause but uses ctx to cancel the request or bound its duration
+func (c *PlayerController) PauseContext(ctx context.Context) error {
+	return c.playback(ctx, "pause", nil)
+}
+
+// Stop stops playback
+func (c *PlayerController) Stop() error {
+	return c.StopContext(context.Background())
+}
+
+// StopContext is like Stop but uses ctx to cancel the request or bound its duration
+func (c *PlayerController) StopContext(ctx context.Context) error {
+	return c.playback(ctx, "stop", nil)
+}
+
+// SeekTo moves playback to offset from the start of the media
+func (c *PlayerController) SeekTo(offset time.Duration) error {
+	return c.SeekToContext(context.Background(), offset)
+}
+
+// SeekToContext is like SeekTo but uses ctx to cancel the request or bound its duration
+func (c *PlayerController) SeekToContext(ctx context.Context, offset time.Duration) error {
+	params := url.Values{}
+
+	params.Set("offset", strconv.FormatInt(offset.Milliseconds(), 10))
+
+	return c.playback(ctx, "seekTo", params)
+}
+
+// SkipNext plays the next item of the play queue
+func (c *PlayerController) SkipNext() error {
+	return c.SkipNextContext(context.Background())
+}
+
+// SkipNextContext is like SkipNext but uses ctx to cancel the request or bound its duration
+func (c *PlayerController) SkipNextContext(ctx context.Context) error {
+	return c.playback(ctx, "skipNext", nil)
+}
+
+// SkipPrevious plays the previous item of the play queue
+func (c *PlayerController) SkipPrevious() error {
+	return c.SkipPreviousContext(context.Background())
+}
+
+// SkipPreviousContext is like SkipPrevious but uses ctx to cancel the request or bound its duration
+func (c *PlayerController) SkipPreviousContext(ctx context.Context) error {
+	return c.playback(ctx, "skipPrevious", nil)
+}
+
+// StepForward skips forward a few seconds
+func (c *PlayerController) StepForward() error {
+	return c.StepForwardContext(context.Background())
+}
+
+// StepForwardContext is like StepForward but uses ctx to cancel the request or bound its duration
+func (c *PlayerController) StepForwardContext(ctx context.Context) error {
+	return c.playback(ctx, "stepForward", nil)
+}
+
+// StepBack skips back a few seconds
+func (c *PlayerController) StepBack() error {
+	return c.StepBackContext(context.Background())
+}
+
+// StepBackContext is like StepBack but uses ctx to cancel the request or bound its duration
+func (c *PlayerController) StepBackContext(ctx context.Context) error {
+	return c.playback(ctx, "stepBack", nil)
+}
+
+// SetVolume sets the volume of the player from 0 to 100
+func (c *PlayerController) SetVolume(volume int) error {
+	return c.SetVolumeContext(context.Background(), volume)
+}
+
+// SetVolumeContext is like SetVolume but uses ctx to cancel the request or bound its duration
+func (c *PlayerController) SetVolumeContext(ctx context.Context, volume int) error {
+	if volume < 0 || volume > 100 {
+		return fmt.Errorf("invalid volume %d", volume)
+	}
+
+	return c.setParameters(ctx, "volume", strconv.Itoa(volume))
+}
+
+// SetShuffle turns shuffling of the play queue on or off
+func (c *PlayerController) SetShuffle(shuffle bool) error {
+	return c.SetShuffleContext(context.Background(), shuffle)
+}
+
+// SetShuffleContext is like SetShuffle but uses ctx to cancel the request or bound its duration
+func (c *PlayerController) SetShuffleContext(ctx context.Context, shuffle bool) error {
+	return c.setParameters(ctx, "shuffle", boolToOneOrZero(shuffle))
+}
+
+// SetRepeat sets the repeat mode of the player. See RepeatOff
+func (c *PlayerController) SetRepeat(mode int) error {
+	return c.SetRepeatContext(context.Background(), mode)
+}
+
+// SetRepeatContext is like SetRepeat but uses ctx to cancel the request or bound its duration
+func (c *PlayerController) SetRepeatContext(ctx context.Context, mode int) error {
+	if mode < RepeatOff || mode > RepeatAll {
+		return fmt.Errorf("invalid repeat mode %d", mode)
+	}
+
+	return c.setParameters(ctx, "repeat", strconv.Itoa(mode))
+}
+
+func (c *PlayerController) setParameters(ctx context.Context, key, value string) error {
+	params := url.Values{}
+
+	params.Set(key, value)
+
+	return c.playback(ctx, "setParameters", params)
+}
+
+// SetStreams selects the audio and subtitle streams of the media that is playing. An empty
+// id leaves the stream unchanged and a subtitleStreamID of "0" turns subtitles off
+func (c *PlayerController) SetStreams(audioStreamID, subtitleStreamID string) error {
+	return c.SetStreamsContext(context.Background(), audioStreamID, subtitleStreamID)
+}
+
+// SetStreamsContext is like SetStreams but uses ctx to cancel the request or bound its duration
+func (c *PlayerController) SetStreamsContext(ctx context.Context, audioStreamID, subtitleStreamID string) error {
+	params := url.Values{}
+
+	if audioStreamID != "" {
+		params.Set("audioStreamID", audioStreamID)
+	}
+
+	if subtitleStreamID != "" {
+		params.Set("subtitleStreamID", subtitleStreamID)
+	}
+
+	if len(params) == 0 {
+		return errors.New("an audio or subtitle stream id is required")
+	}
+
+	return c.playback(ctx, "setStreams", params)
+}
+
+// PlayMedia starts playing a play queue on the player at offset, beginning with its selected item
+func (c *PlayerController) PlayMedia(queue PlayQueue, offset time.Duration) error {
+	return c.PlayMediaContext(context.Background(), queue, offset)
+}
+
+// PlayMediaContext is like PlayMedia but uses ctx to cancel the requests or bound their duration
+func (c *PlayerController) PlayMediaContext(ctx context.Context, queue PlayQueue, offset time.Duration) error {
+	container := queue.MediaContainer
+
+	if len(container.Metadata) == 0 {
+		return errors.New("play queue is empty")
+	}
+
+	selected := container.Metadata[0]
+
+	for _, item := range container.Metadata {
+		if item.PlayQueueItemID == container.PlayQueueSelectedItemID {
+			selected = item
+			break
+		}
+	}
+
+	server, err := url.Parse(c.plex.URL)
+
+	if err != nil {
+		return err
+	}
+
+	port := server.Port()
+
+	if port == "" && server.Scheme == "https" {
+		port = "443"
+	} else if port == "" {
+		port = "80"
+	}
+
+	machineID, err := c.plex.serverIdentifier(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{}
+
+	params.Set("key", "/library/metadata/"+selected.RatingKey)
+	params.Set("offset", strconv.FormatInt(offset.Milliseconds(), 10))
+	params.Set("machineIdentifier", machineID)
+	params.Set("protocol", server.Scheme)
+	params.Set("address", server.Hostname())
+	params.Set("port", port)
+	params.Set("containerKey", fmt.Sprintf("/playQueues/%d?own=1&window=200", container.PlayQueueID))
+
+	if c.plex.Token != "" {
+		params.Set("token", c.plex.Token)
+	}
+
+	return c.playback(ctx, "playMedia", params)
+}
+
+// Navigate sends a navigation key press to the player. See NavigationMoveUp
+func (c *PlayerController) Navigate(command string) error {
+	return c.NavigateContext(context.Background(), command)
+}
+
+// NavigateContext is like Navigate but uses ctx to cancel the request or bound its duration
+func (c *PlayerController) NavigateContext(ctx context.Context, command string) error {
+	switch command {
+	case NavigationMoveUp, NavigationMoveDown, NavigationMoveLeft, NavigationMoveRight,
+		NavigationPageUp, NavigationPageDown, NavigationSelect, NavigationBack,
+		NavigationHome, NavigationContextMenu, NavigationToggleOSD:
+	default:
+		return fmt.Errorf("unknown navigation command %q", command)
+	}
+
+	return c.command(ctx, "/player/navigation/"+command, nil)
+}
+
+// PollTimeline returns the current playback state of the player
+func (c *PlayerController) PollTimeline() (PlayerTimeline, error) {
+	return c.PollTimelineContext(context.Background())
+}
+
+// PollTimelineContext is like PollTimeline but uses ctx to cancel the request or bound its duration
+func (c *PlayerController) PollTimelineContext(ctx context.Context) (PlayerTimeline, error) {
+	params := url.Values{}
+
+	params.Set("wait", "0")
+
+	resp, err := c.send(ctx, "/player/timeline/poll", params, true)
+
+	if err != nil {
+		return PlayerTimeline{}, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return PlayerTimeline{}, newAPIError(resp)
+	}
+
+	var result PlayerTimeline
+
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return PlayerTimeline{}, err
+	}
+
+	return result, nil
+}
+
+// WatchTimeline polls the timeline of the player every interval and passes it to fn until
+// ctx is done or a poll fails. It returns nil when ctx is done
+func (c *PlayerController) WatchTimeline(ctx context.Context, interval time.Duration, fn func(PlayerTimeline)) error {
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		timeline, err := c.PollTimelineContext(ctx)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		fn(timeline)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// SubscribeTimeline asks the player to post its timeline to an http server listening on addr,
+// i.e. ":32500", and passes every timeline to fn until ctx is done or the server fails. The
+// subscription is renewed every minute, as players drop subscribers after 90 seconds, and
+// removed when ctx is done. It returns nil when ctx is done
+func (c *PlayerController) SubscribeTimeline(ctx context.Context, addr string, fn func(PlayerTimeline)) error {
+	listener, err := net.Listen("tcp", addr)
+
+	if err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var timeline PlayerTimeline
+
+		if err := xml.NewDecoder(io.LimitReader(r.Body, maxTimelineSize)).Decode(&timeline); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		// timelines are passed one at a time
+		mu.Lock()
+		defer mu.Unlock()
+
+		fn(timeline)
+	})}
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	defer server.Close()
+
+	params := url.Values{}
+
+	params.Set("protocol", "http")
+	params.Set("port", strconv.Itoa(listener.Addr().(*net.TCPAddr).Port))
+
+	if err := c.command(ctx, "/player/timeline/subscribe", params); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return err
+	}
+
+	ticker := time.NewTicker(timelineSubscriptionRenewal)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			unsubscribeCtx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+			defer cancel()
+
+			c.command(unsubscribeCtx, "/player/timeline/unsubscribe", nil)
+
+			return nil
+		case err := <-served:
+			return err
+		case <-ticker.C:
+			if err := c.command(ctx, "/player/timeline/subscribe", params); err != nil && ctx.Err() == nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package plex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testTimeline = `<MediaContainer commandID="3" location="fullScreenVideo">
+	<Timeline type="music" state="stopped" />
+	<Timeline type="video" state="playing" time="60000" duration="3600000" ratingKey="10" key="/library/metadata/10" playQueueID="7" playQueueItemID="101" volume="80" shuffle="0" repeat="0" controllable="playPause,stop,seekTo,volume" />
+	<Timeline type="photo" state="stopped" />
+</MediaContainer>`
+
+func newPlayerServer(t *testing.T, requests *[]url.URL) (*httptest.Server, *Plex) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/identity" {
+			w.Header().Set("Content-Type", applicationJson)
+			fmt.Fprint(w, `{"MediaContainer":{"size":0,"machineIdentifier":"server123"}}`)
+			return
+		}
+
+		*requests = append(*requests, *r.URL)
+
+		if target := r.Header.Get("X-Plex-Target-Identifier"); target != "player123" {
+			t.Errorf("expected target identifier player123, got %q", target)
+		}
+
+		w.Header().Set("Content-Type", applicationXml)
+
+		if r.URL.Path == "/player/timeline/poll" {
+			fmt.Fprint(w, testTimeline)
+			return
+		}
+
+		fmt.Fprint(w, `<Response code="200" status="OK" />`)
+	}))
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return server, p
+}
+
+func TestPlayerControllerCommands(t *testing.T) {
+	var requests []url.URL
+
+	server, p := newPlayerServer(t, &requests)
+	defer server.Close()
+
+	player := p.NewPlayerController("player123")
+
+	if err := player.Pause(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := player.SeekTo(90 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := player.SetVolume(50); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := player.Navigate(NavigationSelect); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := player.Navigate("jump"); err == nil {
+		t.Error("expected an unknown navigation command to fail")
+	}
+
+	if len(requests) != 4 {
+		t.Fatalf("expected 4 requests, got %d", len(requests))
+	}
+
+	for i, request := range requests {
+		if commandID := request.Query().Get("commandID"); commandID != fmt.Sprint(i+1) {
+			t.Errorf("expected command id %d for %s, got %s", i+1, request.Path, commandID)
+		}
+	}
+
+	if requests[0].Path != "/player/playback/pause" || requests[0].Query().Get("type") != PlayerTypeVideo {
+		t.Errorf("unexpected pause request: %s", requests[0].String())
+	}
+
+	if requests[1].Query().Get("offset") != "90000" {
+		t.Errorf("expected offset in milliseconds, got %s", requests[1].RawQuery)
+	}
+
+	if requests[2].Path != "/player/playback/setParameters" || requests[2].Query().Get("volume") != "50" {
+		t.Errorf("unexpected volume request: %s", requests[2].String())
+	}
+
+	if requests[3].Path != "/player/navigation/select" {
+		t.Errorf("unexpected navigation request: %s", requests[3].String())
+	}
+
+	if player.CommandID() != 4 {
+		t.Errorf("expected last command id to be 4, got %d", player.CommandID())
+	}
+}
+
+func TestPlayerControllerPlayMedia(t *testing.T) {
+	var requests []url.URL
+
+	server, p := newPlayerServer(t, &requests)
+	defer server.Close()
+
+	var queue PlayQueue
+
+	queue.MediaContainer.PlayQueueID = 7
+	queue.MediaContainer.PlayQueueSelectedItemID = 102
+	queue.MediaContainer.Metadata = []Metadata{
+		{RatingKey: "10", PlayQueueItemID: 101},
+		{RatingKey: "11", PlayQueueItemID: 102},
+	}
+
+	if err := p.NewPlayerController("player123").PlayMedia(queue, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	query := requests[0].Query()
+	serverURL, _ := url.Parse(server.URL)
+
+	expected := map[string]string{
+		"key":               "/library/metadata/11",
+		"machineIdentifier": "server123",
+		"containerKey":      "/playQueues/7?own=1&window=200",
+		"address":           serverURL.Hostname(),
+		"port":              serverURL.Port(),
+		"protocol":          "http",
+		"offset":            "0",
+	}
+
+	for key, value := range expected {
+		if query.Get(key) != value {
+			t.Errorf("expected %s to be %q, got %q", key, value, query.Get(key))
+		}
+	}
+}
+
+func TestPlayerControllerTimeline(t *testing.T) {
+	var requests []url.URL
+
+	server, p := newPlayerServer(t, &requests)
+	defer server.Close()
+
+	player := p.NewPlayerController("player123")
+
+	timeline, err := player.PollTimeline()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	active, ok := timeline.Active()
+
+	if !ok || active.Type != "video" || active.Time != 60000 || active.Volume != 80 {
+		t.Errorf("unexpected active timeline: %+v", active)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	polls := 0
+
+	err = player.WatchTimeline(ctx, time.Millisecond, func(timeline PlayerTimeline) {
+		polls++
+
+		if polls == 3 {
+			cancel()
+		}
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if polls != 3 {
+		t.Errorf("expected 3 polls, got %d", polls)
+	}
+}
+
+func TestPlayerControllerCommandsNotRetried(t *testing.T) {
+	var attempts, polls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/player/timeline/poll" {
+			atomic.AddInt32(&polls, 1)
+		} else {
+			atomic.AddInt32(&attempts, 1)
+		}
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123", WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player := p.NewPlayerController("player123")
+
+	if err := player.SkipNext(); err == nil {
+		t.Fatal("expected the command to fail")
+	}
+
+	if attempts != 1 {
+		t.Errorf("expected a single attempt of the command, got %d", attempts)
+	}
+
+	player.PollTimeline()
+
+	if polls != 3 {
+		t.Errorf("expected polls to be retried, got %d attempts", polls)
+	}
+}
+
+func TestPlayerControllerSubscribeTimeline(t *testing.T) {
+	var (
+		mu           sync.Mutex
+		unsubscribed bool
+	)
+
+	// the server relays the subscription and the player posts its timeline to the subscriber
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/player/timeline/subscribe":
+			if r.URL.Query().Get("protocol") != "http" {
+				t.Errorf("expected the http protocol, got %q", r.URL.Query().Get("protocol"))
+			}
+
+			subscriber := "http://127.0.0.1:" + r.URL.Query().Get("port") + "/:/timeline"
+
+			go func() {
+				resp, err := http.Post(subscriber, applicationXml, strings.NewReader(testTimeline))
+
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				resp.Body.Close()
+			}()
+		case "/player/timeline/unsubscribe":
+			mu.Lock()
+			unsubscribed = true
+			mu.Unlock()
+		}
+
+		fmt.Fprint(w, `<Response code="200" status="OK" />`)
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player := p.NewPlayerController("player123")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var received PlayerTimeline
+
+	err = player.SubscribeTimeline(ctx, "127.0.0.1:0", func(timeline PlayerTimeline) {
+		received = timeline
+		cancel()
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if active, ok := received.Active(); !ok || active.RatingKey != "10" {
+		t.Errorf("expected the posted timeline, got %+v", received)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !unsubscribed {
+		t.Error("expected the subscription to be removed")
+	}
+}
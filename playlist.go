@@ -0,0 +1,215 @@
+package plex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Playlist types
+const (
+	PlaylistTypeAudio = "audio"
+	PlaylistTypeVideo = "video"
+	PlaylistTypePhoto = "photo"
+)
+
+// Playlist is a regular or smart playlist on your plex server
+type Playlist struct {
+	AddedAt      int    `json:"addedAt"`
+	Composite    string `json:"composite"`
+	Duration     int    `json:"duration"`
+	GUID         string `json:"guid"`
+	Icon         string `json:"icon"`
+	Key          string `json:"key"`
+	LastViewedAt int    `json:"lastViewedAt"`
+	LeafCount    int    `json:"leafCount"`
+	PlaylistType string `json:"playlistType"`
+	RatingKey    string `json:"ratingKey"`
+	Smart        bool   `json:"smart"`
+	Summary      string `json:"summary"`
+	Title        string `json:"title"`
+	Type         string `json:"type"`
+	UpdatedAt    int    `json:"updatedAt"`
+	ViewCount    int    `json:"viewCount"`
+}
+
+// PlaylistContainer is the response of the playlist endpoints
+type PlaylistContainer struct {
+	MediaContainer struct {
+		Metadata []Playlist `json:"Metadata"`
+		Size     int        `json:"size"`
+	} `json:"MediaContainer"`
+}
+
+// GetPlaylists lists the playlists on your plex server. An empty playlistType lists every playlist
+func (p *Plex) GetPlaylists(playlistType string) ([]Playlist, error) {
+	return p.GetPlaylistsContext(context.Background(), playlistType)
+}
+
+// GetPlaylistsContext is like GetPlaylists but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetPlaylistsContext(ctx context.Context, playlistType string) ([]Playlist, error) {
+	query := p.URL + "/playlists"
+
+	if playlistType != "" {
+		query += "?playlistType=" + url.QueryEscape(playlistType)
+	}
+
+	var result PlaylistContainer
+
+	if err := p.requestJSON(ctx, http.MethodGet, query, &result); err != nil {
+		return []Playlist{}, err
+	}
+
+	return result.MediaContainer.Metadata, nil
+}
+
+// CreatePlaylist creates an audio, video or photo playlist containing the media of ratingKeys
+func (p *Plex) CreatePlaylist(title, playlistType string, ratingKeys []string) (Playlist, error) {
+	return p.CreatePlaylistContext(context.Background(), title, playlistType, ratingKeys)
+}
+
+// CreatePlaylistContext is like CreatePlaylist but uses ctx to cancel the requests or bound their duration
+func (p *Plex) CreatePlaylistContext(ctx context.Context, title, playlistType string, ratingKeys []string) (Playlist, error) {
+	if len(ratingKeys) == 0 {
+		return Playlist{}, errors.New("at least one rating key is required")
+	}
+
+	uri, err := p.libraryURI(ctx, "/library/metadata/"+strings.Join(ratingKeys, ","))
+
+	if err != nil {
+		return Playlist{}, err
+	}
+
+	return p.createPlaylist(ctx, title, playlistType, uri, false)
+}
+
+// CreateSmartPlaylist creates a playlist that is kept up to date with the content of a
+// library section matching filter. filter is a query suffix such as the one rendered by Filter.String
+func (p *Plex) CreateSmartPlaylist(title, playlistType, sectionKey, filter string) (Playlist, error) {
+	return p.CreateSmartPlaylistContext(context.Background(), title, playlistType, sectionKey, filter)
+}
+
+// CreateSmartPlaylistContext is like CreateSmartPlaylist but uses ctx to cancel the requests or bound their duration
+func (p *Plex) CreateSmartPlaylistContext(ctx context.Context, title, playlistType, sectionKey, filter string) (Playlist, error) {
+	uri, err := p.libraryURI(ctx, fmt.Sprintf("/library/sections/%s/all%s", sectionKey, filter))
+
+	if err != nil {
+		return Playlist{}, err
+	}
+
+	return p.createPlaylist(ctx, title, playlistType, uri, true)
+}
+
+func (p *Plex) createPlaylist(ctx context.Context, title, playlistType, uri string, smart bool) (Playlist, error) {
+	if title == "" {
+		return Playlist{}, errors.New("a title is required")
+	}
+
+	switch playlistType {
+	case PlaylistTypeAudio, PlaylistTypeVideo, PlaylistTypePhoto:
+	default:
+		return Playlist{}, fmt.Errorf("invalid playlist type %q", playlistType)
+	}
+
+	params := url.Values{}
+
+	params.Set("title", title)
+	params.Set("type", playlistType)
+	params.Set("smart", boolToOneOrZero(smart))
+	params.Set("uri", uri)
+
+	var result PlaylistContainer
+
+	if err := p.requestJSON(ctx, http.MethodPost, p.URL+"/playlists?"+params.Encode(), &result); err != nil {
+		return Playlist{}, err
+	}
+
+	if len(result.MediaContainer.Metadata) == 0 {
+		return Playlist{}, errors.New("plex did not return the new playlist")
+	}
+
+	return result.MediaContainer.Metadata[0], nil
+}
+
+// AddToPlaylist appends the media of ratingKeys to a playlist
+func (p *Plex) AddToPlaylist(playlistID int, ratingKeys []string) error {
+	return p.AddToPlaylistContext(context.Background(), playlistID, ratingKeys)
+}
+
+// AddToPlaylistContext is like AddToPlaylist but uses ctx to cancel the requests or bound their duration
+func (p *Plex) AddToPlaylistContext(ctx context.Context, playlistID int, ratingKeys []string) error {
+	if len(ratingKeys) == 0 {
+		return errors.New("at least one rating key is required")
+	}
+
+	uri, err := p.libraryURI(ctx, "/library/metadata/"+strings.Join(ratingKeys, ","))
+
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("%s/playlists/%d/items?uri=%s", p.URL, playlistID, url.QueryEscape(uri))
+
+	return p.requestJSON(ctx, http.MethodPut, query, nil)
+}
+
+// RemoveFromPlaylist removes an item from a playlist. playlistItemID is the PlaylistItemID
+// of the item returned by GetPlaylist, not its rating key
+func (p *Plex) RemoveFromPlaylist(playlistID, playlistItemID int) error {
+	return p.RemoveFromPlaylistContext(context.Background(), playlistID, playlistItemID)
+}
+
+// RemoveFromPlaylistContext is like RemoveFromPlaylist but uses ctx to cancel the request or bound its duration
+func (p *Plex) RemoveFromPlaylistContext(ctx context.Context, playlistID, playlistItemID int) error {
+	query := fmt.Sprintf("%s/playlists/%d/items/%d", p.URL, playlistID, playlistItemID)
+
+	return p.requestJSON(ctx, http.MethodDelete, query, nil)
+}
+
+// MovePlaylistItem moves an item of a playlist right after the item afterItemID.
+// An afterItemID of 0 moves the item to the top of the playlist
+func (p *Plex) MovePlaylistItem(playlistID, playlistItemID, afterItemID int) error {
+	return p.MovePlaylistItemContext(context.Background(), playlistID, playlistItemID, afterItemID)
+}
+
+// MovePlaylistItemContext is like MovePlaylistItem but uses ctx to cancel the request or bound its duration
+func (p *Plex) MovePlaylistItemContext(ctx context.Context, playlistID, playlistItemID, afterItemID int) error {
+	query := fmt.Sprintf("%s/playlists/%d/items/%d/move", p.URL, playlistID, playlistItemID)
+
+	if afterItemID > 0 {
+		query += fmt.Sprintf("?after=%d", afterItemID)
+	}
+
+	return p.requestJSON(ctx, http.MethodPut, query, nil)
+}
+
+// RenamePlaylist changes the title of a playlist
+func (p *Plex) RenamePlaylist(playlistID int, title string) error {
+	return p.RenamePlaylistContext(context.Background(), playlistID, title)
+}
+
+// RenamePlaylistContext is like RenamePlaylist but uses ctx to cancel the request or bound its duration
+func (p *Plex) RenamePlaylistContext(ctx context.Context, playlistID int, title string) error {
+	if title == "" {
+		return errors.New("a title is required")
+	}
+
+	query := fmt.Sprintf("%s/playlists/%d?title=%s", p.URL, playlistID, url.QueryEscape(title))
+
+	return p.requestJSON(ctx, http.MethodPut, query, nil)
+}
+
+// DeletePlaylist deletes a playlist. The media in it is left untouched
+func (p *Plex) DeletePlaylist(playlistID int) error {
+	return p.DeletePlaylistContext(context.Background(), playlistID)
+}
+
+// DeletePlaylistContext is like DeletePlaylist but uses ctx to cancel the request or bound its duration
+func (p *Plex) DeletePlaylistContext(ctx context.Context, playlistID int) error {
+	query := fmt.Sprintf("%s/playlists/%d", p.URL, playlistID)
+
+	return p.requestJSON(ctx, http.MethodDelete, query, nil)
+}
@@ -0,0 +1,148 @@
+package plex
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const playlistFixture = `{"MediaContainer":{"size":1,"Metadata":[{"ratingKey":"42","key":"/playlists/42/items","type":"playlist","title":"Road Trip","smart":false,"playlistType":"audio","leafCount":2,"duration":420000}]}}`
+
+func newPlaylistServer(t *testing.T, requests *[]string) (*httptest.Server, *Plex) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+
+		w.Header().Set("Content-Type", applicationJson)
+
+		switch {
+		case r.URL.Path == "/identity":
+			fmt.Fprint(w, `{"MediaContainer":{"size":0,"machineIdentifier":"abc123"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/playlists":
+			if r.URL.Query().Get("playlistType") != "audio" {
+				t.Errorf("expected audio playlists to be requested, got %s", r.URL.RawQuery)
+			}
+
+			fmt.Fprint(w, playlistFixture)
+		case r.Method == http.MethodPost && r.URL.Path == "/playlists":
+			query := r.URL.Query()
+
+			if query.Get("title") != "Road Trip" || query.Get("type") != PlaylistTypeAudio {
+				t.Errorf("unexpected playlist params: %s", r.URL.RawQuery)
+			}
+
+			expectedURI := "server://abc123/com.plexapp.plugins.library/library/metadata/1,2"
+
+			if query.Get("smart") == "1" {
+				expectedURI = "server://abc123/com.plexapp.plugins.library/library/sections/3/all?type=10"
+			}
+
+			if query.Get("uri") != expectedURI {
+				t.Errorf("expected uri %s, got %s", expectedURI, query.Get("uri"))
+			}
+
+			fmt.Fprint(w, playlistFixture)
+		case r.Method == http.MethodPut && r.URL.Path == "/playlists/42/items/7/move":
+			if r.URL.Query().Get("after") != "9" {
+				t.Errorf("expected item to be moved after 9, got %s", r.URL.RawQuery)
+			}
+		case r.Method == http.MethodPut && r.URL.Path == "/playlists/42":
+			if r.URL.Query().Get("title") != "Road Trip 2" {
+				t.Errorf("unexpected rename params: %s", r.URL.RawQuery)
+			}
+		case r.URL.Path == "/playlists/42/items", r.URL.Path == "/playlists/42/items/7", r.URL.Path == "/playlists/42":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return server, p
+}
+
+func TestGetPlaylists(t *testing.T) {
+	var requests []string
+
+	server, p := newPlaylistServer(t, &requests)
+	defer server.Close()
+
+	playlists, err := p.GetPlaylists(PlaylistTypeAudio)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(playlists) != 1 || playlists[0].Title != "Road Trip" || playlists[0].LeafCount != 2 {
+		t.Errorf("unexpected playlists: %+v", playlists)
+	}
+}
+
+func TestCreatePlaylist(t *testing.T) {
+	var requests []string
+
+	server, p := newPlaylistServer(t, &requests)
+	defer server.Close()
+
+	playlist, err := p.CreatePlaylist("Road Trip", PlaylistTypeAudio, []string{"1", "2"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if playlist.RatingKey != "42" {
+		t.Errorf("expected new playlist 42, got %s", playlist.RatingKey)
+	}
+
+	if _, err := p.CreateSmartPlaylist("Road Trip", PlaylistTypeAudio, "3", NewFilter().Type("track").String()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.CreatePlaylist("Road Trip", "podcast", []string{"1"}); err == nil {
+		t.Error("expected an invalid playlist type to fail")
+	}
+}
+
+func TestEditPlaylist(t *testing.T) {
+	var requests []string
+
+	server, p := newPlaylistServer(t, &requests)
+	defer server.Close()
+
+	if err := p.AddToPlaylist(42, []string{"1", "2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.MovePlaylistItem(42, 7, 9); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.RemoveFromPlaylist(42, 7); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.RenamePlaylist(42, "Road Trip 2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.DeletePlaylist(42); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"GET /identity",
+		"PUT /playlists/42/items",
+		"PUT /playlists/42/items/7/move",
+		"DELETE /playlists/42/items/7",
+		"PUT /playlists/42",
+		"DELETE /playlists/42",
+	}
+
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+}
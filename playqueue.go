@@ -0,0 +1,206 @@
+package plex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// PlayQueueOptions controls how a new play queue plays its items
+type PlayQueueOptions struct {
+	Shuffle bool
+	Repeat  bool
+	// Continuous keeps playing the following items of the source, i.e. the next episodes of a show
+	Continuous bool
+	// Key is the rating key of the item to start with. Defaults to the first item
+	Key string
+}
+
+// PlayQueue is an ordered list of items a client plays
+type PlayQueue struct {
+	MediaContainer struct {
+		Identifier                      string     `json:"identifier"`
+		MediaTagPrefix                  string     `json:"mediaTagPrefix"`
+		MediaTagVersion                 int        `json:"mediaTagVersion"`
+		Metadata                        []Metadata `json:"Metadata"`
+		PlayQueueID                     int        `json:"playQueueID"`
+		PlayQueueLastAddedItemID        string     `json:"playQueueLastAddedItemID"`
+		PlayQueueSelectedItemID         int        `json:"playQueueSelectedItemID"`
+		PlayQueueSelectedItemOffset     int        `json:"playQueueSelectedItemOffset"`
+		PlayQueueSelectedMetadataItemID string     `json:"playQueueSelectedMetadataItemID"`
+		PlayQueueShuffled               bool       `json:"playQueueShuffled"`
+		PlayQueueSourceURI              string     `json:"playQueueSourceURI"`
+		PlayQueueTotalCount             int        `json:"playQueueTotalCount"`
+		PlayQueueVersion                int        `json:"playQueueVersion"`
+		Size                            int        `json:"size"`
+	} `json:"MediaContainer"`
+}
+
+// CreatePlayQueue creates a play queue starting with the media of ratingKey. For a show,
+// season or album every child is queued. mediaType is video, audio or photo (see PlaylistTypeVideo)
+func (p *Plex) CreatePlayQueue(mediaType, ratingKey string, opts PlayQueueOptions) (PlayQueue, error) {
+	return p.CreatePlayQueueContext(context.Background(), mediaType, ratingKey, opts)
+}
+
+// CreatePlayQueueContext is like CreatePlayQueue but uses ctx to cancel the requests or bound their duration
+func (p *Plex) CreatePlayQueueContext(ctx context.Context, mediaType, ratingKey string, opts PlayQueueOptions) (PlayQueue, error) {
+	uri, err := p.libraryURI(ctx, "/library/metadata/"+ratingKey)
+
+	if err != nil {
+		return PlayQueue{}, err
+	}
+
+	params := playQueueParams(mediaType, opts)
+
+	params.Set("uri", uri)
+
+	return p.createPlayQueue(ctx, params)
+}
+
+// CreatePlayQueueFromPlaylist creates a play queue of the items of a playlist
+func (p *Plex) CreatePlayQueueFromPlaylist(mediaType string, playlistID int, opts PlayQueueOptions) (PlayQueue, error) {
+	return p.CreatePlayQueueFromPlaylistContext(context.Background(), mediaType, playlistID, opts)
+}
+
+// CreatePlayQueueFromPlaylistContext is like CreatePlayQueueFromPlaylist but uses ctx to cancel the request or bound its duration
+func (p *Plex) CreatePlayQueueFromPlaylistContext(ctx context.Context, mediaType string, playlistID int, opts PlayQueueOptions) (PlayQueue, error) {
+	params := playQueueParams(mediaType, opts)
+
+	params.Set("playlistID", strconv.Itoa(playlistID))
+
+	return p.createPlayQueue(ctx, params)
+}
+
+// CreatePlayQueueFromFilter creates a play queue of the content of a library section matching
+// filter. filter is a query suffix such as the one rendered by Filter.String
+func (p *Plex) CreatePlayQueueFromFilter(mediaType, sectionKey, filter string, opts PlayQueueOptions) (PlayQueue, error) {
+	return p.CreatePlayQueueFromFilterContext(context.Background(), mediaType, sectionKey, filter, opts)
+}
+
+// CreatePlayQueueFromFilterContext is like CreatePlayQueueFromFilter but uses ctx to cancel the requests or bound their duration
+func (p *Plex) CreatePlayQueueFromFilterContext(ctx context.Context, mediaType, sectionKey, filter string, opts PlayQueueOptions) (PlayQueue, error) {
+	uri, err := p.libraryURI(ctx, fmt.Sprintf("/library/sections/%s/all%s", sectionKey, filter))
+
+	if err != nil {
+		return PlayQueue{}, err
+	}
+
+	params := playQueueParams(mediaType, opts)
+
+	params.Set("uri", uri)
+
+	return p.createPlayQueue(ctx, params)
+}
+
+func playQueueParams(mediaType string, opts PlayQueueOptions) url.Values {
+	params := url.Values{}
+
+	params.Set("type", mediaType)
+	params.Set("shuffle", boolToOneOrZero(opts.Shuffle))
+	params.Set("repeat", boolToOneOrZero(opts.Repeat))
+	params.Set("continuous", boolToOneOrZero(opts.Continuous))
+	params.Set("own", "1")
+
+	if opts.Key != "" {
+		params.Set("key", "/library/metadata/"+opts.Key)
+	}
+
+	return params
+}
+
+func (p *Plex) createPlayQueue(ctx context.Context, params url.Values) (PlayQueue, error) {
+	return p.playQueueRequest(ctx, http.MethodPost, "/playQueues?"+params.Encode())
+}
+
+// GetPlayQueue fetches a play queue and its items
+func (p *Plex) GetPlayQueue(playQueueID int) (PlayQueue, error) {
+	return p.GetPlayQueueContext(context.Background(), playQueueID)
+}
+
+// GetPlayQueueContext is like GetPlayQueue but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetPlayQueueContext(ctx context.Context, playQueueID int) (PlayQueue, error) {
+	return p.playQueueRequest(ctx, http.MethodGet, fmt.Sprintf("/playQueues/%d", playQueueID))
+}
+
+// AddToPlayQueue adds the media of ratingKey to a play queue. When playNext is true
+// it plays after the current item, otherwise at the end of the queue
+func (p *Plex) AddToPlayQueue(playQueueID int, ratingKey string, playNext bool) (PlayQueue, error) {
+	return p.AddToPlayQueueContext(context.Background(), playQueueID, ratingKey, playNext)
+}
+
+// AddToPlayQueueContext is like AddToPlayQueue but uses ctx to cancel the requests or bound their duration
+func (p *Plex) AddToPlayQueueContext(ctx context.Context, playQueueID int, ratingKey string, playNext bool) (PlayQueue, error) {
+	uri, err := p.libraryURI(ctx, "/library/metadata/"+ratingKey)
+
+	if err != nil {
+		return PlayQueue{}, err
+	}
+
+	params := url.Values{}
+
+	params.Set("uri", uri)
+	params.Set("next", boolToOneOrZero(playNext))
+
+	return p.playQueueRequest(ctx, http.MethodPut, fmt.Sprintf("/playQueues/%d?%s", playQueueID, params.Encode()))
+}
+
+// MovePlayQueueItem moves an item of a play queue right after the item afterItemID.
+// An afterItemID of 0 moves the item to the top of the queue
+func (p *Plex) MovePlayQueueItem(playQueueID, playQueueItemID, afterItemID int) (PlayQueue, error) {
+	return p.MovePlayQueueItemContext(context.Background(), playQueueID, playQueueItemID, afterItemID)
+}
+
+// MovePlayQueueItemContext is like MovePlayQueueItem but uses ctx to cancel the request or bound its duration
+func (p *Plex) MovePlayQueueItemContext(ctx context.Context, playQueueID, playQueueItemID, afterItemID int) (PlayQueue, error) {
+	path := fmt.Sprintf("/playQueues/%d/items/%d/move", playQueueID, playQueueItemID)
+
+	if afterItemID > 0 {
+		path += fmt.Sprintf("?after=%d", afterItemID)
+	}
+
+	return p.playQueueRequest(ctx, http.MethodPut, path)
+}
+
+// RemoveFromPlayQueue removes an item from a play queue. playQueueItemID is the PlayQueueItemID
+// of the item, not its rating key
+func (p *Plex) RemoveFromPlayQueue(playQueueID, playQueueItemID int) (PlayQueue, error) {
+	return p.RemoveFromPlayQueueContext(context.Background(), playQueueID, playQueueItemID)
+}
+
+// RemoveFromPlayQueueContext is like RemoveFromPlayQueue but uses ctx to cancel the request or bound its duration
+func (p *Plex) RemoveFromPlayQueueContext(ctx context.Context, playQueueID, playQueueItemID int) (PlayQueue, error) {
+	return p.playQueueRequest(ctx, http.MethodDelete, fmt.Sprintf("/playQueues/%d/items/%d", playQueueID, playQueueItemID))
+}
+
+// ShufflePlayQueue shuffles the items of a play queue after the current item
+func (p *Plex) ShufflePlayQueue(playQueueID int) (PlayQueue, error) {
+	return p.ShufflePlayQueueContext(context.Background(), playQueueID)
+}
+
+// ShufflePlayQueueContext is like ShufflePlayQueue but uses ctx to cancel the request or bound its duration
+func (p *Plex) ShufflePlayQueueContext(ctx context.Context, playQueueID int) (PlayQueue, error) {
+	return p.playQueueRequest(ctx, http.MethodPut, fmt.Sprintf("/playQueues/%d/shuffle", playQueueID))
+}
+
+// UnshufflePlayQueue restores the original order of a shuffled play queue
+func (p *Plex) UnshufflePlayQueue(playQueueID int) (PlayQueue, error) {
+	return p.UnshufflePlayQueueContext(context.Background(), playQueueID)
+}
+
+// UnshufflePlayQueueContext is like UnshufflePlayQueue but uses ctx to cancel the request or bound its duration
+func (p *Plex) UnshufflePlayQueueContext(ctx context.Context, playQueueID int) (PlayQueue, error) {
+	return p.playQueueRequest(ctx, http.MethodPut, fmt.Sprintf("/playQueues/%d/unshuffle", playQueueID))
+}
+
+// playQueueRequest sends a request to path and decodes the updated play queue
+func (p *Plex) playQueueRequest(ctx context.Context, method, path string) (PlayQueue, error) {
+	var result PlayQueue
+
+	if err := p.requestJSON(ctx, method, p.URL+path, &result); err != nil {
+		return PlayQueue{}, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,122 @@
+package plex
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testPlayQueue = `{"MediaContainer":{"size":2,"identifier":"com.plexapp.plugins.library","playQueueID":7,"playQueueSelectedItemID":101,"playQueueSelectedItemOffset":0,"playQueueSelectedMetadataItemID":"10","playQueueShuffled":false,"playQueueSourceURI":"server://abc123/com.plexapp.plugins.library/library/metadata/10","playQueueTotalCount":2,"playQueueVersion":1,"Metadata":[{"ratingKey":"10","title":"Pilot","type":"episode","playQueueItemID":101},{"ratingKey":"11","title":"Cat's in the Bag...","type":"episode","playQueueItemID":102}]}}`
+
+func TestCreatePlayQueue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", applicationJson)
+
+		switch r.URL.Path {
+		case "/identity":
+			fmt.Fprint(w, `{"MediaContainer":{"size":0,"machineIdentifier":"abc123"}}`)
+		case "/playQueues":
+			query := r.URL.Query()
+
+			if query.Get("type") != PlaylistTypeVideo || query.Get("shuffle") != "1" || query.Get("continuous") != "1" || query.Get("repeat") != "0" {
+				t.Errorf("unexpected play queue params: %s", r.URL.RawQuery)
+			}
+
+			if uri := "server://abc123/com.plexapp.plugins.library/library/metadata/10"; query.Get("uri") != uri {
+				t.Errorf("expected uri %s, got %s", uri, query.Get("uri"))
+			}
+
+			fmt.Fprint(w, testPlayQueue)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queue, err := p.CreatePlayQueue(PlaylistTypeVideo, "10", PlayQueueOptions{Shuffle: true, Continuous: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if queue.MediaContainer.PlayQueueID != 7 || len(queue.MediaContainer.Metadata) != 2 {
+		t.Fatalf("unexpected play queue: %+v", queue.MediaContainer)
+	}
+
+	if queue.MediaContainer.Metadata[1].PlayQueueItemID != 102 {
+		t.Errorf("expected play queue item id 102, got %d", queue.MediaContainer.Metadata[1].PlayQueueItemID)
+	}
+}
+
+func TestEditPlayQueue(t *testing.T) {
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		w.Header().Set("Content-Type", applicationJson)
+
+		switch r.URL.Path {
+		case "/identity":
+			fmt.Fprint(w, `{"MediaContainer":{"size":0,"machineIdentifier":"abc123"}}`)
+		case "/playQueues/7":
+			if r.Method == http.MethodPut && r.URL.Query().Get("next") != "1" {
+				t.Errorf("expected item to be played next, got %s", r.URL.RawQuery)
+			}
+
+			fmt.Fprint(w, testPlayQueue)
+		case "/playQueues/7/items/102/move":
+			if r.URL.Query().Get("after") != "" {
+				t.Errorf("expected item to be moved to the top, got %s", r.URL.RawQuery)
+			}
+
+			fmt.Fprint(w, testPlayQueue)
+		case "/playQueues/7/items/101":
+			fmt.Fprint(w, testPlayQueue)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.GetPlayQueue(7); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.AddToPlayQueue(7, "12", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.MovePlayQueueItem(7, 102, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.RemoveFromPlayQueue(7, 101); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"GET /playQueues/7",
+		"GET /identity",
+		"PUT /playQueues/7",
+		"PUT /playQueues/7/items/102/move",
+		"DELETE /playQueues/7/items/101",
+	}
+
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+}
@@ -3,25 +3,21 @@ package plex
 // plex is a Plex Media Server and Plex.tv client
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
 	"runtime"
-	"strings"
-	"time"
 
 	"github.com/google/uuid"
 )
 
 const (
-	plexURL         = "https://plex.tv"
+	plexURL         = "https://plex.tv" // default plex.tv endpoint, see Plex.PlexTVURL
 	applicationXml  = "application/xml"
 	applicationJson = "application/json"
 )
@@ -36,8 +32,6 @@ func defaultHeaders() headers {
 		Version:          version,
 		Device:           runtime.GOOS + " " + runtime.GOARCH,
 		ClientIdentifier: "go-plex-client-v" + version,
-		ContainerSize:    "Plex-Container-Size=50",
-		ContainerStart:   "X-Plex-Container-Start=0",
 		Accept:           applicationJson,
 		ContentType:      applicationJson,
 	}
@@ -45,7 +39,7 @@ func defaultHeaders() headers {
 
 // New creates a new plex instance that is required to
 // to make requests to your Plex Media Server
-func New(baseURL, token string) (*Plex, error) {
+func New(baseURL, token string, opts ...Option) (*Plex, error) {
 	var p Plex
 
 	// allow empty url so caller can use GetServers() to set the server url later
@@ -55,11 +49,15 @@ func New(baseURL, token string) (*Plex, error) {
 	}
 
 	p.HTTPClient = http.Client{
-		Timeout: 3 * time.Second,
+		Timeout: defaultTimeout,
 	}
 
 	p.DownloadClient = http.Client{}
 
+	for _, opt := range opts {
+		opt(&p)
+	}
+
 	p.Headers = defaultHeaders()
 	// id, err := uuid.NewRandom()
 
@@ -96,21 +94,23 @@ func New(baseURL, token string) (*Plex, error) {
 
 // SignIn creates a plex instance using a user name and password instead of an auth
 // token.
-func SignIn(username, password string) (*Plex, error) {
+func SignIn(username, password string, opts ...Option) (*Plex, error) {
+	return SignInContext(context.Background(), username, password, opts...)
+}
+
+// SignInContext is like SignIn but uses ctx to cancel the request or bound its duration
+func SignInContext(ctx context.Context, username, password string, opts ...Option) (*Plex, error) {
 	id, err := uuid.NewRandom()
 
 	if err != nil {
 		return &Plex{}, err
 	}
 
-	p := Plex{
-		ClientIdentifier: id.String(),
-		HTTPClient: http.Client{
-			Timeout: 3 * time.Second,
-		},
-	}
+	p := newAnonymousClient(opts...)
+
+	p.ClientIdentifier = id.String()
 
-	query := plexURL + "/api/v2/users/signin"
+	query := p.plexTVURL() + "/api/v2/users/signin"
 
 	// Encode login in the specific format they require
 	body := url.Values{}
@@ -123,7 +123,7 @@ func SignIn(username, password string) (*Plex, error) {
 	// Doesn't like having a content type, even form-data
 	newHeaders.ContentType = "application/x-www-form-urlencoded"
 	newHeaders.Accept = applicationJson
-	resp, err := p.post(query, []byte(body.Encode()), newHeaders)
+	resp, err := p.post(ctx, query, []byte(body.Encode()), newHeaders)
 
 	if err != nil {
 		return &Plex{}, err
@@ -132,7 +132,7 @@ func SignIn(username, password string) (*Plex, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		return &Plex{}, errors.New(resp.Status)
+		return &Plex{}, newAPIError(resp)
 	}
 
 	var signInResponse SignInResponse
@@ -143,11 +143,20 @@ func SignIn(username, password string) (*Plex, error) {
 
 	p.Token = signInResponse.AuthToken
 
-	return &p, err
+	return p, err
 }
 
 // Search your Plex Server for media
 func (p *Plex) Search(title string) (SearchResults, error) {
+	return p.SearchContext(context.Background(), title)
+}
+
+// SearchContext is like Search but uses ctx to cancel the request or bound its duration
+func (p *Plex) SearchContext(ctx context.Context, title string) (SearchResults, error) {
+	return p.search(ctx, title, p.Headers)
+}
+
+func (p *Plex) search(ctx context.Context, title string, h headers) (SearchResults, error) {
 	if title == "" {
 		return SearchResults{}, fmt.Errorf(ErrorCommon, ErrorTitleRequired)
 	}
@@ -157,19 +166,18 @@ func (p *Plex) Search(title string) (SearchResults, error) {
 
 	var results SearchResults
 
-	resp, err := p.get(query, p.Headers)
+	resp, err := p.get(ctx, query, h)
 
 	if err != nil {
 		return SearchResults{}, err
 	}
 
-	// Unauthorized
-	if resp.StatusCode == http.StatusUnauthorized {
-		return SearchResults{}, errors.New(ErrorNotAuthorized)
-	}
-
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return SearchResults{}, newAPIError(resp)
+	}
+
 	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
 		return SearchResults{}, err
 	}
@@ -179,6 +187,11 @@ func (p *Plex) Search(title string) (SearchResults, error) {
 
 // GetMetadata can get some media info
 func (p *Plex) GetMetadata(key string) (MediaMetadata, error) {
+	return p.GetMetadataContext(context.Background(), key)
+}
+
+// GetMetadataContext is like GetMetadata but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetMetadataContext(ctx context.Context, key string) (MediaMetadata, error) {
 	if key == "" {
 		return MediaMetadata{}, fmt.Errorf(ErrorCommon, ErrorKeyIsRequired)
 	}
@@ -189,18 +202,18 @@ func (p *Plex) GetMetadata(key string) (MediaMetadata, error) {
 
 	newHeaders := p.Headers
 
-	resp, err := p.get(query, newHeaders)
+	resp, err := p.get(ctx, query, newHeaders)
 
 	if err != nil {
 		return results, err
 	}
 
+	defer resp.Body.Close()
+
 	if resp.StatusCode != http.StatusOK {
-		return results, fmt.Errorf(ErrorServer, resp.Status)
+		return results, newAPIError(resp)
 	}
 
-	defer resp.Body.Close()
-
 	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
 		return results, err
 	}
@@ -210,6 +223,11 @@ func (p *Plex) GetMetadata(key string) (MediaMetadata, error) {
 
 // GetMetadataChildren can get a show's season titles. My use-case would be getting the season titles after using Search()
 func (p *Plex) GetMetadataChildren(key string) (MetadataChildren, error) {
+	return p.GetMetadataChildrenContext(context.Background(), key)
+}
+
+// GetMetadataChildrenContext is like GetMetadataChildren but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetMetadataChildrenContext(ctx context.Context, key string) (MetadataChildren, error) {
 	if key == "" {
 		return MetadataChildren{}, fmt.Errorf(ErrorCommon, ErrorKeyIsRequired)
 	}
@@ -218,19 +236,18 @@ func (p *Plex) GetMetadataChildren(key string) (MetadataChildren, error) {
 
 	newHeaders := p.Headers
 
-	resp, err := p.get(query, newHeaders)
+	resp, err := p.get(ctx, query, newHeaders)
 
 	if err != nil {
 		return MetadataChildren{}, err
 	}
 
-	// Unauthorized
-	if resp.StatusCode == http.StatusUnauthorized {
-		return MetadataChildren{}, errors.New(ErrorNotAuthorized)
-	}
-
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return MetadataChildren{}, newAPIError(resp)
+	}
+
 	var results MetadataChildren
 
 	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
@@ -242,25 +259,29 @@ func (p *Plex) GetMetadataChildren(key string) (MetadataChildren, error) {
 
 // GetEpisodes returns episodes of a season of a show
 func (p *Plex) GetEpisodes(key string) (SearchResultsEpisode, error) {
+	return p.GetEpisodesContext(context.Background(), key)
+}
+
+// GetEpisodesContext is like GetEpisodes but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetEpisodesContext(ctx context.Context, key string) (SearchResultsEpisode, error) {
 	if key == "" {
 		return SearchResultsEpisode{}, fmt.Errorf(ErrorCommon, ErrorKeyIsRequired)
 	}
 
 	query := fmt.Sprintf("%s/library/metadata/%s/children", p.URL, key)
 
-	resp, err := p.get(query, p.Headers)
+	resp, err := p.get(ctx, query, p.Headers)
 
 	if err != nil {
 		return SearchResultsEpisode{}, err
 	}
 
-	// Unauthorized
-	if resp.StatusCode == http.StatusUnauthorized {
-		return SearchResultsEpisode{}, errors.New(ErrorNotAuthorized)
-	}
-
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return SearchResultsEpisode{}, newAPIError(resp)
+	}
+
 	var results SearchResultsEpisode
 
 	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
@@ -272,25 +293,29 @@ func (p *Plex) GetEpisodes(key string) (SearchResultsEpisode, error) {
 
 // GetEpisode returns a single episode of a show.
 func (p *Plex) GetEpisode(key string) (SearchResultsEpisode, error) {
+	return p.GetEpisodeContext(context.Background(), key)
+}
+
+// GetEpisodeContext is like GetEpisode but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetEpisodeContext(ctx context.Context, key string) (SearchResultsEpisode, error) {
 	if key == "" {
 		return SearchResultsEpisode{}, fmt.Errorf(ErrorCommon, ErrorKeyIsRequired)
 	}
 
 	query := fmt.Sprintf("%s/library/metadata/%s", p.URL, key)
 
-	resp, err := p.get(query, p.Headers)
+	resp, err := p.get(ctx, query, p.Headers)
 
 	if err != nil {
 		return SearchResultsEpisode{}, err
 	}
 
-	// Unauthorized
-	if resp.StatusCode == http.StatusUnauthorized {
-		return SearchResultsEpisode{}, errors.New(ErrorNotAuthorized)
-	}
-
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return SearchResultsEpisode{}, newAPIError(resp)
+	}
+
 	var results SearchResultsEpisode
 
 	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
@@ -302,21 +327,25 @@ func (p *Plex) GetEpisode(key string) (SearchResultsEpisode, error) {
 
 // GetOnDeck gets the on-deck videos.
 func (p *Plex) GetOnDeck() (SearchResultsEpisode, error) {
+	return p.GetOnDeckContext(context.Background())
+}
+
+// GetOnDeckContext is like GetOnDeck but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetOnDeckContext(ctx context.Context) (SearchResultsEpisode, error) {
 	query := fmt.Sprintf("%s/library/onDeck", p.URL)
 
-	resp, err := p.get(query, p.Headers)
+	resp, err := p.get(ctx, query, p.Headers)
 
 	if err != nil {
 		return SearchResultsEpisode{}, err
 	}
 
-	// Unauthorized
-	if resp.StatusCode == http.StatusUnauthorized {
-		return SearchResultsEpisode{}, errors.New(ErrorNotAuthorized)
-	}
-
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return SearchResultsEpisode{}, newAPIError(resp)
+	}
+
 	var results SearchResultsEpisode
 
 	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
@@ -326,91 +355,51 @@ func (p *Plex) GetOnDeck() (SearchResultsEpisode, error) {
 	return results, nil
 }
 
-// Download media associated with metadata
+// Download media associated with metadata. Interrupted downloads are resumed, see DownloadAll
 func (p *Plex) Download(meta Metadata, path string, createFolders bool, skipIfExists bool) error {
+	return p.DownloadContext(context.Background(), meta, path, createFolders, skipIfExists)
+}
 
+// DownloadContext is like Download but uses ctx to cancel the requests or bound their duration
+func (p *Plex) DownloadContext(ctx context.Context, meta Metadata, path string, createFolders bool, skipIfExists bool) error {
 	if len(meta.Media) == 0 {
 		return fmt.Errorf("no media associated with metadata, skipping")
 	}
 
-	path = filepath.Join(path)
-	if createFolders {
-
-		if meta.ParentTitle != "" && meta.GrandparentTitle != "" { // for tv shows and music
-			path = filepath.Join(path, meta.GrandparentTitle, meta.ParentTitle)
-		} else { // for movies
-			path = filepath.Join(path, meta.Title)
-		}
-		if err := os.MkdirAll(path, 0700); err != nil {
-			return err
-		}
-	}
-
-	for _, media := range meta.Media {
-
-		for _, part := range media.Part {
-
-			// get original filename from original path
-			split := strings.Split(part.File, "/")
-			file := split[len(split)-1]
-
-			fp := filepath.Join(path, file)
-
-			_, exists := os.Stat(fp)
-
-			if exists == nil && skipIfExists {
-				return nil
-			}
-
-			query := fmt.Sprintf("%s%s?download=1", p.URL, part.Key)
-
-			resp, err := p.grab(query, p.Headers)
-			if err != nil {
-				return err
-			}
-
-			// Unauthorized
-			if resp.StatusCode == http.StatusUnauthorized {
-				return errors.New(ErrorNotAuthorized)
-			}
-
-			out, err := os.Create(fp)
-			if err != nil {
-				return err
-			}
-			defer out.Close()
-
-			_, err = io.Copy(out, resp.Body)
+	_, err := p.DownloadAllContext(ctx, []Metadata{meta}, path, DownloadOptions{
+		CreateFolders: createFolders,
+		SkipIfExists:  skipIfExists,
+		Concurrency:   1,
+	})
 
-			if err != nil {
-				return err
-			}
-
-		}
-	}
-
-	return nil
+	return err
 }
 
 // GetPlaylist gets all videos in a playlist.
 func (p *Plex) GetPlaylist(key int) (SearchResultsEpisode, error) {
+	return p.GetPlaylistContext(context.Background(), key)
+}
+
+// GetPlaylistContext is like GetPlaylist but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetPlaylistContext(ctx context.Context, key int) (SearchResultsEpisode, error) {
+	return p.getPlaylist(ctx, key, p.Headers)
+}
+
+func (p *Plex) getPlaylist(ctx context.Context, key int, h headers) (SearchResultsEpisode, error) {
 	query := fmt.Sprintf("%s/playlists/%d/items", p.URL, key)
 
-	resp, err := p.get(query, p.Headers)
+	resp, err := p.get(ctx, query, h)
 
 	if err != nil {
 		return SearchResultsEpisode{}, err
 	}
 
-	// Unauthorized
-	if resp.StatusCode == http.StatusUnauthorized {
-		return SearchResultsEpisode{}, errors.New(ErrorNotAuthorized)
-	} else if resp.StatusCode != http.StatusOK {
-		return SearchResultsEpisode{}, fmt.Errorf(ErrorServer, resp.Status)
-	}
-
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return SearchResultsEpisode{}, newAPIError(resp)
+	}
+
 	var results SearchResultsEpisode
 
 	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
@@ -423,14 +412,24 @@ func (p *Plex) GetPlaylist(key int) (SearchResultsEpisode, error) {
 // GetThumbnail returns the response of a request to pms thumbnail
 // My ideal use case would be to proxy a request to pms without exposing the plex token
 func (p *Plex) GetThumbnail(key, thumbnailID string) (*http.Response, error) {
+	return p.GetThumbnailContext(context.Background(), key, thumbnailID)
+}
+
+// GetThumbnailContext is like GetThumbnail but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetThumbnailContext(ctx context.Context, key, thumbnailID string) (*http.Response, error) {
 	query := fmt.Sprintf("%s/library/metadata/%s/thumb/%s", p.URL, key, thumbnailID)
 
-	return p.get(query, p.Headers)
+	return p.get(ctx, query, p.Headers)
 }
 
 // Test your connection to your Plex Media Server
 func (p *Plex) Test() (bool, error) {
-	resp, err := p.get(plexURL+"/api/servers", p.Headers)
+	return p.TestContext(context.Background())
+}
+
+// TestContext is like Test but uses ctx to cancel the request or bound its duration
+func (p *Plex) TestContext(ctx context.Context) (bool, error) {
+	resp, err := p.get(ctx, p.plexTVURL()+"/api/servers", p.Headers)
 
 	if err != nil {
 		return false, err
@@ -438,10 +437,8 @@ func (p *Plex) Test() (bool, error) {
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusUnauthorized {
-		return false, errors.New(ErrorNotAuthorized)
-	} else if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf(ErrorServerReplied, resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return false, newAPIError(resp)
 	}
 
 	return true, nil
@@ -449,6 +446,11 @@ func (p *Plex) Test() (bool, error) {
 
 // KillTranscodeSession stops a transcode session
 func (p *Plex) KillTranscodeSession(sessionKey string) (bool, error) {
+	return p.KillTranscodeSessionContext(context.Background(), sessionKey)
+}
+
+// KillTranscodeSessionContext is like KillTranscodeSession but uses ctx to cancel the request or bound its duration
+func (p *Plex) KillTranscodeSessionContext(ctx context.Context, sessionKey string) (bool, error) {
 
 	if sessionKey == "" {
 		return false, errors.New(ErrorMissingSessionKey)
@@ -456,7 +458,7 @@ func (p *Plex) KillTranscodeSession(sessionKey string) (bool, error) {
 
 	query := p.URL + "/video/:/transcode/universal/stop?session=" + sessionKey
 
-	resp, err := p.get(query, p.Headers)
+	resp, err := p.get(ctx, query, p.Headers)
 
 	if err != nil {
 		return false, err
@@ -464,10 +466,8 @@ func (p *Plex) KillTranscodeSession(sessionKey string) (bool, error) {
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusUnauthorized {
-		return false, errors.New(ErrorNotAuthorized)
-	} else if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf(ErrorServerReplied, resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return false, newAPIError(resp)
 	}
 
 	return true, nil
@@ -475,11 +475,16 @@ func (p *Plex) KillTranscodeSession(sessionKey string) (bool, error) {
 
 // GetTranscodeSessions retrieves a list of all active transcode sessions
 func (p *Plex) GetTranscodeSessions() (TranscodeSessionsResponse, error) {
+	return p.GetTranscodeSessionsContext(context.Background())
+}
+
+// GetTranscodeSessionsContext is like GetTranscodeSessions but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetTranscodeSessionsContext(ctx context.Context) (TranscodeSessionsResponse, error) {
 	var result TranscodeSessionsResponse
 
 	query := p.URL + "/transcode/sessions"
 
-	resp, err := p.get(query, p.Headers)
+	resp, err := p.get(ctx, query, p.Headers)
 
 	if err != nil {
 		return result, err
@@ -487,10 +492,8 @@ func (p *Plex) GetTranscodeSessions() (TranscodeSessionsResponse, error) {
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusUnauthorized {
-		return result, errors.New(ErrorNotAuthorized)
-	} else if resp.StatusCode != http.StatusOK {
-		return result, fmt.Errorf(ErrorServerReplied, resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return result, newAPIError(resp)
 	}
 
 	return result, json.NewDecoder(resp.Body).Decode(&result)
@@ -499,11 +502,16 @@ func (p *Plex) GetTranscodeSessions() (TranscodeSessionsResponse, error) {
 
 // GetPlexTokens not sure if it works
 func (p *Plex) GetPlexTokens(token string) (DevicesResponse, error) {
+	return p.GetPlexTokensContext(context.Background(), token)
+}
+
+// GetPlexTokensContext is like GetPlexTokens but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetPlexTokensContext(ctx context.Context, token string) (DevicesResponse, error) {
 	var result DevicesResponse
 
-	query := plexURL + "/devices.json"
+	query := p.plexTVURL() + "/devices.json"
 
-	resp, err := p.get(query, p.Headers)
+	resp, err := p.get(ctx, query, p.Headers)
 
 	if err != nil {
 		return result, err
@@ -511,10 +519,8 @@ func (p *Plex) GetPlexTokens(token string) (DevicesResponse, error) {
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusUnauthorized {
-		return result, errors.New(ErrorNotAuthorized)
-	} else if resp.StatusCode != http.StatusOK {
-		return result, fmt.Errorf(ErrorServerReplied, resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return result, newAPIError(resp)
 	}
 
 	return result, json.NewDecoder(resp.Body).Decode(&result)
@@ -522,11 +528,16 @@ func (p *Plex) GetPlexTokens(token string) (DevicesResponse, error) {
 
 // DeletePlexToken is currently not tested
 func (p *Plex) DeletePlexToken(token string) (bool, error) {
+	return p.DeletePlexTokenContext(context.Background(), token)
+}
+
+// DeletePlexTokenContext is like DeletePlexToken but uses ctx to cancel the request or bound its duration
+func (p *Plex) DeletePlexTokenContext(ctx context.Context, token string) (bool, error) {
 	var result bool
 
-	query := plexURL + "/devices/" + token + ".json"
+	query := p.plexTVURL() + "/devices/" + token + ".json"
 
-	resp, err := p.get(query, p.Headers)
+	resp, err := p.get(ctx, query, p.Headers)
 
 	if err != nil {
 		return result, err
@@ -534,10 +545,8 @@ func (p *Plex) DeletePlexToken(token string) (bool, error) {
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusUnauthorized {
-		return result, errors.New(ErrorNotAuthorized)
-	} else if resp.StatusCode != http.StatusOK {
-		return result, fmt.Errorf(ErrorServerReplied, resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return result, newAPIError(resp)
 	}
 
 	return result, json.NewDecoder(resp.Body).Decode(&result)
@@ -545,16 +554,21 @@ func (p *Plex) DeletePlexToken(token string) (bool, error) {
 
 // GetFriends returns all of your plex friends
 func (p *Plex) GetFriends() ([]Friends, error) {
+	return p.GetFriendsContext(context.Background())
+}
+
+// GetFriendsContext is like GetFriends but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetFriendsContext(ctx context.Context) ([]Friends, error) {
 
 	var plexFriendsResp friendsResponse
 
-	query := plexURL + "/api/users"
+	query := p.plexTVURL() + "/api/users"
 
 	newHeaders := p.Headers
 
 	newHeaders.Accept = applicationXml
 
-	resp, err := p.get(query, newHeaders)
+	resp, err := p.get(ctx, query, newHeaders)
 
 	if err != nil {
 		return []Friends{}, err
@@ -562,10 +576,8 @@ func (p *Plex) GetFriends() ([]Friends, error) {
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusUnauthorized {
-		return []Friends{}, errors.New(ErrorNotAuthorized)
-	} else if resp.StatusCode != http.StatusOK {
-		return []Friends{}, fmt.Errorf(ErrorServerReplied, resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return []Friends{}, newAPIError(resp)
 	}
 
 	respBytes, err := ioutil.ReadAll(resp.Body)
@@ -593,10 +605,15 @@ func (p *Plex) GetFriends() ([]Friends, error) {
 
 // RemoveFriend from your friend's list which stops access to your Plex server
 func (p *Plex) RemoveFriend(id string) (bool, error) {
+	return p.RemoveFriendContext(context.Background(), id)
+}
 
-	query := plexURL + "/api/friends/" + id
+// RemoveFriendContext is like RemoveFriend but uses ctx to cancel the request or bound its duration
+func (p *Plex) RemoveFriendContext(ctx context.Context, id string) (bool, error) {
 
-	resp, err := p.delete(query, p.Headers)
+	query := p.plexTVURL() + "/api/friends/" + id
+
+	resp, err := p.delete(ctx, query, p.Headers)
 
 	if err != nil {
 		return false, err
@@ -605,7 +622,7 @@ func (p *Plex) RemoveFriend(id string) (bool, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
-		return false, errors.New(resp.Status)
+		return false, newAPIError(resp)
 	}
 
 	result := new(resultResponse)
@@ -619,10 +636,15 @@ func (p *Plex) RemoveFriend(id string) (bool, error) {
 
 // InviteFriend to access your Plex server. Add restrictions to media or give them full access.
 func (p *Plex) InviteFriend(params InviteFriendParams) error {
+	return p.InviteFriendContext(context.Background(), params)
+}
+
+// InviteFriendContext is like InviteFriend but uses ctx to cancel the request or bound its duration
+func (p *Plex) InviteFriendContext(ctx context.Context, params InviteFriendParams) error {
 
 	label := url.QueryEscape(params.Label)
 
-	query := fmt.Sprintf("%s/api/v2/shared_servers", plexURL)
+	query := fmt.Sprintf("%s/api/v2/shared_servers", p.plexTVURL())
 
 	var requestBody inviteFriendBody
 
@@ -645,7 +667,7 @@ func (p *Plex) InviteFriend(params InviteFriendParams) error {
 		return jsonErr
 	}
 
-	resp, err := p.post(query, jsonBody, p.Headers)
+	resp, err := p.post(ctx, query, jsonBody, p.Headers)
 
 	if err != nil {
 		return err
@@ -654,7 +676,7 @@ func (p *Plex) InviteFriend(params InviteFriendParams) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		return errors.New(resp.Status)
+		return newAPIError(resp)
 	}
 
 	result := new(inviteFriendResponse)
@@ -668,6 +690,11 @@ func (p *Plex) InviteFriend(params InviteFriendParams) error {
 
 // UpdateFriendAccess limit your friends access to your plex server
 func (p *Plex) UpdateFriendAccess(userID string, params UpdateFriendParams) (bool, error) {
+	return p.UpdateFriendAccessContext(context.Background(), userID, params)
+}
+
+// UpdateFriendAccessContext is like UpdateFriendAccess but uses ctx to cancel the request or bound its duration
+func (p *Plex) UpdateFriendAccessContext(ctx context.Context, userID string, params UpdateFriendParams) (bool, error) {
 	// Fix any defaults to statisfy what plex expects
 	if params.AllowSync == "" {
 		params.AllowSync = "0"
@@ -681,7 +708,7 @@ func (p *Plex) UpdateFriendAccess(userID string, params UpdateFriendParams) (boo
 		params.AllowChannels = "0"
 	}
 
-	query := fmt.Sprintf("%s/api/friends/%s", plexURL, userID)
+	query := fmt.Sprintf("%s/api/friends/%s", p.plexTVURL(), userID)
 
 	parsedQuery, parseErr := url.Parse(query)
 
@@ -703,7 +730,7 @@ func (p *Plex) UpdateFriendAccess(userID string, params UpdateFriendParams) (boo
 
 	query = parsedQuery.String()
 
-	resp, err := p.put(query, nil, p.Headers)
+	resp, err := p.put(ctx, query, nil, p.Headers)
 
 	if err != nil {
 		return false, err
@@ -712,7 +739,7 @@ func (p *Plex) UpdateFriendAccess(userID string, params UpdateFriendParams) (boo
 	resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return false, errors.New(resp.Status)
+		return false, newAPIError(resp)
 	}
 
 	return true, nil
@@ -720,9 +747,14 @@ func (p *Plex) UpdateFriendAccess(userID string, params UpdateFriendParams) (boo
 
 // RemoveFriendAccessToLibrary you can individually revoke access to a library on your server. Such as movies, tv shows, music, etc
 func (p *Plex) RemoveFriendAccessToLibrary(userID, machineID, serverID string) (bool, error) {
-	query := fmt.Sprintf("%s/api/servers/%s/shared_servers/%s", plexURL, machineID, serverID)
+	return p.RemoveFriendAccessToLibraryContext(context.Background(), userID, machineID, serverID)
+}
 
-	resp, err := p.delete(query, p.Headers)
+// RemoveFriendAccessToLibraryContext is like RemoveFriendAccessToLibrary but uses ctx to cancel the request or bound its duration
+func (p *Plex) RemoveFriendAccessToLibraryContext(ctx context.Context, userID, machineID, serverID string) (bool, error) {
+	query := fmt.Sprintf("%s/api/servers/%s/shared_servers/%s", p.plexTVURL(), machineID, serverID)
+
+	resp, err := p.delete(ctx, query, p.Headers)
 
 	if err != nil {
 		return false, err
@@ -731,7 +763,7 @@ func (p *Plex) RemoveFriendAccessToLibrary(userID, machineID, serverID string) (
 	resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return false, errors.New(resp.Status)
+		return false, newAPIError(resp)
 	}
 
 	return true, nil
@@ -739,24 +771,28 @@ func (p *Plex) RemoveFriendAccessToLibrary(userID, machineID, serverID string) (
 
 // GetInvitedFriends get all invited friends with request still pending
 func (p *Plex) GetInvitedFriends() ([]InvitedFriend, error) {
+	return p.GetInvitedFriendsContext(context.Background())
+}
 
-	query := plexURL + "/api/invites/requested"
+// GetInvitedFriendsContext is like GetInvitedFriends but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetInvitedFriendsContext(ctx context.Context) ([]InvitedFriend, error) {
+
+	query := p.plexTVURL() + "/api/invites/requested"
 	newHeaders := p.Headers
 	newHeaders.Accept = applicationXml
 
-	resp, err := p.get(query, newHeaders)
+	resp, err := p.get(ctx, query, newHeaders)
 	if err != nil {
 		return []InvitedFriend{}, err
 	}
 
-	if resp.StatusCode == http.StatusUnauthorized {
-		return []InvitedFriend{}, errors.New(ErrorNotAuthorized)
-	} else if resp.StatusCode != http.StatusOK {
-		return []InvitedFriend{}, fmt.Errorf(ErrorServerReplied, resp.StatusCode)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return []InvitedFriend{}, newAPIError(resp)
 	}
 
 	var invitedFriendsResp invitedFriendsResponse
-	defer resp.Body.Close()
 	err = xml.NewDecoder(resp.Body).Decode(&invitedFriendsResp)
 	if err != nil {
 		return []InvitedFriend{}, err
@@ -767,7 +803,12 @@ func (p *Plex) GetInvitedFriends() ([]InvitedFriend, error) {
 
 // RemoveInvitedFriend cancel pending friend invite
 func (p *Plex) RemoveInvitedFriend(inviteID string, isFriend, isServer, isHome bool) (bool, error) {
-	query := plexURL + "/api/invites/requested/" + url.QueryEscape(inviteID)
+	return p.RemoveInvitedFriendContext(context.Background(), inviteID, isFriend, isServer, isHome)
+}
+
+// RemoveInvitedFriendContext is like RemoveInvitedFriend but uses ctx to cancel the request or bound its duration
+func (p *Plex) RemoveInvitedFriendContext(ctx context.Context, inviteID string, isFriend, isServer, isHome bool) (bool, error) {
+	query := p.plexTVURL() + "/api/invites/requested/" + url.QueryEscape(inviteID)
 
 	parsedQuery, parseErr := url.Parse(query)
 	if parseErr != nil {
@@ -783,14 +824,14 @@ func (p *Plex) RemoveInvitedFriend(inviteID string, isFriend, isServer, isHome b
 
 	query = parsedQuery.String()
 
-	resp, err := p.delete(query, p.Headers)
+	resp, err := p.delete(ctx, query, p.Headers)
 	if err != nil {
 		return false, err
 	}
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
-		return false, errors.New(resp.Status)
+		return false, newAPIError(resp)
 	}
 
 	result := new(resultResponse)
@@ -803,12 +844,17 @@ func (p *Plex) RemoveInvitedFriend(inviteID string, isFriend, isServer, isHome b
 
 // CheckUsernameOrEmail will check if the username is a Plex user or will verify an email is valid
 func (p *Plex) CheckUsernameOrEmail(usernameOrEmail string) (bool, error) {
+	return p.CheckUsernameOrEmailContext(context.Background(), usernameOrEmail)
+}
+
+// CheckUsernameOrEmailContext is like CheckUsernameOrEmail but uses ctx to cancel the request or bound its duration
+func (p *Plex) CheckUsernameOrEmailContext(ctx context.Context, usernameOrEmail string) (bool, error) {
 
 	usernameOrEmail = url.QueryEscape(usernameOrEmail)
 
-	query := fmt.Sprintf("%s/api/users/validate?invited_email=%s", plexURL, usernameOrEmail)
+	query := fmt.Sprintf("%s/api/users/validate?invited_email=%s", p.plexTVURL(), usernameOrEmail)
 
-	resp, err := p.post(query, nil, p.Headers)
+	resp, err := p.post(ctx, query, nil, p.Headers)
 
 	if err != nil {
 		return false, err
@@ -817,7 +863,7 @@ func (p *Plex) CheckUsernameOrEmail(usernameOrEmail string) (bool, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
-		return false, errors.New(resp.Status)
+		return false, newAPIError(resp)
 	}
 
 	result := new(resultResponse)
@@ -831,6 +877,11 @@ func (p *Plex) CheckUsernameOrEmail(usernameOrEmail string) (bool, error) {
 
 // StopPlayback acts as a remote controller and sends the 'stop' command
 func (p *Plex) StopPlayback(machineID string) error {
+	return p.StopPlaybackContext(context.Background(), machineID)
+}
+
+// StopPlaybackContext is like StopPlayback but uses ctx to cancel the request or bound its duration
+func (p *Plex) StopPlaybackContext(ctx context.Context, machineID string) error {
 	query := p.URL + "/player/playback/stop"
 
 	newHeaders := p.Headers
@@ -838,14 +889,16 @@ func (p *Plex) StopPlayback(machineID string) error {
 	newHeaders.Accept = applicationXml
 	newHeaders.TargetClientIdentifier = machineID
 
-	resp, err := p.get(query, newHeaders)
+	resp, err := p.get(ctx, query, newHeaders)
 
 	if err != nil {
 		return err
 	}
 
+	defer resp.Body.Close()
+
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf(resp.Status)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -853,9 +906,14 @@ func (p *Plex) StopPlayback(machineID string) error {
 
 // GetDevices returns a list of your Plex devices (servers, players, controllers, etc)
 func (p *Plex) GetDevices() ([]PMSDevices, error) {
-	query := plexURL + "/api/resources?includeHttps=1"
+	return p.GetDevicesContext(context.Background())
+}
 
-	resp, err := p.get(query, p.Headers)
+// GetDevicesContext is like GetDevices but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetDevicesContext(ctx context.Context) ([]PMSDevices, error) {
+	query := p.plexTVURL() + "/api/resources?includeHttps=1"
+
+	resp, err := p.get(ctx, query, p.Headers)
 
 	if err != nil {
 		return []PMSDevices{}, err
@@ -866,7 +924,7 @@ func (p *Plex) GetDevices() ([]PMSDevices, error) {
 	result := new(resourcesResponse)
 
 	if resp.StatusCode != http.StatusOK {
-		return []PMSDevices{}, errors.New(resp.Status)
+		return []PMSDevices{}, newAPIError(resp)
 	}
 
 	if err := xml.NewDecoder(resp.Body).Decode(result); err != nil {
@@ -880,12 +938,17 @@ func (p *Plex) GetDevices() ([]PMSDevices, error) {
 
 // GetServers returns a list of your Plex servers
 func (p *Plex) GetServers() ([]PMSDevices, error) {
+	return p.GetServersContext(context.Background())
+}
+
+// GetServersContext is like GetServers but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetServersContext(ctx context.Context) ([]PMSDevices, error) {
 
 	// we can use the https://<pms-ip>/media/providers endpoint
 	// but if the caller does not know the ip beforehand, we can grab it
 	// from plex.tv so we'll use https://plex.tv endpoint to give that option
 
-	devices, err := p.GetDevices()
+	devices, err := p.GetDevicesContext(ctx)
 
 	if err != nil {
 		return devices, err
@@ -907,9 +970,14 @@ func (p *Plex) GetServers() ([]PMSDevices, error) {
 
 // GetServersInfo returns info about all of your Plex servers
 func (p *Plex) GetServersInfo() (ServerInfo, error) {
-	query := plexURL + "/api/servers"
+	return p.GetServersInfoContext(context.Background())
+}
+
+// GetServersInfoContext is like GetServersInfo but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetServersInfoContext(ctx context.Context) (ServerInfo, error) {
+	query := p.plexTVURL() + "/api/servers"
 
-	resp, err := p.get(query, p.Headers)
+	resp, err := p.get(ctx, query, p.Headers)
 
 	if err != nil {
 		return ServerInfo{}, err
@@ -918,7 +986,7 @@ func (p *Plex) GetServersInfo() (ServerInfo, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return ServerInfo{}, errors.New(resp.Status)
+		return ServerInfo{}, newAPIError(resp)
 	}
 
 	result := ServerInfo{}
@@ -934,11 +1002,16 @@ func (p *Plex) GetServersInfo() (ServerInfo, error) {
 
 // GetMachineID returns the machine id of the server with the associated access token
 func (p *Plex) GetMachineID() (string, error) {
+	return p.GetMachineIDContext(context.Background())
+}
+
+// GetMachineIDContext is like GetMachineID but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetMachineIDContext(ctx context.Context) (string, error) {
 	if p.Token == "" {
 		return "", errors.New("a token is required to fetch machine id")
 	}
 
-	servers, err := p.GetServersInfo()
+	servers, err := p.GetServersInfoContext(ctx)
 
 	if err != nil {
 		return "", err
@@ -962,13 +1035,18 @@ func (p *Plex) GetMachineID() (string, error) {
 // GetSections of your plex server. This is useful when inviting a user
 // as you can restrict the invited user to a library (i.e. Movie's, TV Shows)
 func (p *Plex) GetSections(machineID string) ([]ServerSections, error) {
-	query := fmt.Sprintf("%s/api/servers/%s", plexURL, machineID)
+	return p.GetSectionsContext(context.Background(), machineID)
+}
+
+// GetSectionsContext is like GetSections but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetSectionsContext(ctx context.Context, machineID string) ([]ServerSections, error) {
+	query := fmt.Sprintf("%s/api/servers/%s", p.plexTVURL(), machineID)
 
 	newHeaders := p.Headers
 
 	newHeaders.Accept = applicationXml
 
-	resp, err := p.get(query, newHeaders)
+	resp, err := p.get(ctx, query, newHeaders)
 
 	if err != nil {
 		return []ServerSections{}, err
@@ -976,6 +1054,10 @@ func (p *Plex) GetSections(machineID string) ([]ServerSections, error) {
 
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return []ServerSections{}, newAPIError(resp)
+	}
+
 	var result SectionIDResponse
 
 	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
@@ -999,9 +1081,14 @@ func (p *Plex) GetSections(machineID string) ([]ServerSections, error) {
 // GetLibraries of your Plex server. My ideal use-case would be
 // to get library count to determine label index
 func (p *Plex) GetLibraries() (LibrarySections, error) {
+	return p.GetLibrariesContext(context.Background())
+}
+
+// GetLibrariesContext is like GetLibraries but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetLibrariesContext(ctx context.Context) (LibrarySections, error) {
 	query := fmt.Sprintf("%s/library/sections", p.URL)
 
-	resp, err := p.get(query, p.Headers)
+	resp, err := p.get(ctx, query, p.Headers)
 
 	if err != nil {
 		return LibrarySections{}, err
@@ -1010,7 +1097,7 @@ func (p *Plex) GetLibraries() (LibrarySections, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return LibrarySections{}, errors.New(resp.Status)
+		return LibrarySections{}, newAPIError(resp)
 	}
 
 	var result LibrarySections
@@ -1026,28 +1113,29 @@ func (p *Plex) GetLibraries() (LibrarySections, error) {
 
 // GetLibraryContent retrieve the content inside a library
 func (p *Plex) GetLibraryContent(sectionKey string, filter string) (SearchResults, error) {
+	return p.GetLibraryContentContext(context.Background(), sectionKey, filter)
+}
+
+// GetLibraryContentContext is like GetLibraryContent but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetLibraryContentContext(ctx context.Context, sectionKey string, filter string) (SearchResults, error) {
+	return p.getLibraryContent(ctx, sectionKey, filter, p.Headers)
+}
+
+func (p *Plex) getLibraryContent(ctx context.Context, sectionKey, filter string, h headers) (SearchResults, error) {
 	query := fmt.Sprintf("%s/library/sections/%s/all%s", p.URL, sectionKey, filter)
 
-	resp, err := p.get(query, p.Headers)
+	resp, err := p.get(ctx, query, h)
 
 	if err != nil {
 		return SearchResults{}, err
 	}
 
-	if resp.Status == ErrorInvalidToken {
-		return SearchResults{}, errors.New("invalid token")
-	}
-
-	if resp.StatusCode == http.StatusUnauthorized {
-		return SearchResults{}, errors.New(ErrorNotAuthorized)
-	}
+	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusBadRequest {
-		return SearchResults{}, errors.New("there was an error in the request")
+	if resp.StatusCode != http.StatusOK {
+		return SearchResults{}, newAPIError(resp)
 	}
 
-	defer resp.Body.Close()
-
 	var results SearchResults
 
 	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
@@ -1059,6 +1147,11 @@ func (p *Plex) GetLibraryContent(sectionKey string, filter string) (SearchResult
 
 // CreateLibrary will create a new library on your Plex server
 func (p *Plex) CreateLibrary(params CreateLibraryParams) error {
+	return p.CreateLibraryContext(context.Background(), params)
+}
+
+// CreateLibraryContext is like CreateLibrary but uses ctx to cancel the request or bound its duration
+func (p *Plex) CreateLibraryContext(ctx context.Context, params CreateLibraryParams) error {
 	// all params are required
 	if params.Name == "" {
 		return errors.New("name is required")
@@ -1105,7 +1198,7 @@ func (p *Plex) CreateLibrary(params CreateLibraryParams) error {
 
 	query = parsedQuery.String()
 
-	resp, err := p.post(query, nil, p.Headers)
+	resp, err := p.post(ctx, query, nil, p.Headers)
 
 	if err != nil {
 		return err
@@ -1114,7 +1207,7 @@ func (p *Plex) CreateLibrary(params CreateLibraryParams) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		return errors.New(resp.Status)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -1122,9 +1215,14 @@ func (p *Plex) CreateLibrary(params CreateLibraryParams) error {
 
 // DeleteLibrary removes the library from your Plex server via library key (or id)
 func (p *Plex) DeleteLibrary(key string) error {
+	return p.DeleteLibraryContext(context.Background(), key)
+}
+
+// DeleteLibraryContext is like DeleteLibrary but uses ctx to cancel the request or bound its duration
+func (p *Plex) DeleteLibraryContext(ctx context.Context, key string) error {
 	query := fmt.Sprintf("%s/library/sections/%s", p.URL, key)
 
-	resp, err := p.delete(query, p.Headers)
+	resp, err := p.delete(ctx, query, p.Headers)
 
 	if err != nil {
 		return err
@@ -1133,7 +1231,7 @@ func (p *Plex) DeleteLibrary(key string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return errors.New(resp.Status)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -1141,9 +1239,14 @@ func (p *Plex) DeleteLibrary(key string) error {
 
 // DeleteMediaByID removes the media from your Plex server via media key (or id)
 func (p *Plex) DeleteMediaByID(id string) error {
+	return p.DeleteMediaByIDContext(context.Background(), id)
+}
+
+// DeleteMediaByIDContext is like DeleteMediaByID but uses ctx to cancel the request or bound its duration
+func (p *Plex) DeleteMediaByIDContext(ctx context.Context, id string) error {
 	query := fmt.Sprintf("%s/library/metadata/%s", p.URL, id)
 
-	resp, err := p.delete(query, p.Headers)
+	resp, err := p.delete(ctx, query, p.Headers)
 
 	if err != nil {
 		return err
@@ -1152,7 +1255,7 @@ func (p *Plex) DeleteMediaByID(id string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return errors.New(resp.Status)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -1160,6 +1263,11 @@ func (p *Plex) DeleteMediaByID(id string) error {
 
 // GetLibraryLabels of your plex server
 func (p *Plex) GetLibraryLabels(sectionKey, sectionIndex string) (LibraryLabels, error) {
+	return p.GetLibraryLabelsContext(context.Background(), sectionKey, sectionIndex)
+}
+
+// GetLibraryLabelsContext is like GetLibraryLabels but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetLibraryLabelsContext(ctx context.Context, sectionKey, sectionIndex string) (LibraryLabels, error) {
 
 	if sectionIndex == "" {
 		sectionIndex = "1"
@@ -1167,7 +1275,7 @@ func (p *Plex) GetLibraryLabels(sectionKey, sectionIndex string) (LibraryLabels,
 
 	query := fmt.Sprintf("%s/library/sections/%s/labels?type=%s", p.URL, sectionKey, sectionIndex)
 
-	resp, err := p.get(query, p.Headers)
+	resp, err := p.get(ctx, query, p.Headers)
 
 	if err != nil {
 		return LibraryLabels{}, err
@@ -1176,7 +1284,7 @@ func (p *Plex) GetLibraryLabels(sectionKey, sectionIndex string) (LibraryLabels,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return LibraryLabels{}, errors.New(resp.Status)
+		return LibraryLabels{}, newAPIError(resp)
 	}
 
 	var result LibraryLabels
@@ -1195,6 +1303,11 @@ func (p *Plex) GetLibraryLabels(sectionKey, sectionIndex string) (LibraryLabels,
 // 1. A reference to the plex media types: https://github.com/Arcanemagus/plex-api/wiki/MediaTypes
 // XXX: Currently plex is capitalizing the first letter
 func (p *Plex) AddLabelToMedia(mediaType, sectionID, id, label, locked string) (bool, error) {
+	return p.AddLabelToMediaContext(context.Background(), mediaType, sectionID, id, label, locked)
+}
+
+// AddLabelToMediaContext is like AddLabelToMedia but uses ctx to cancel the request or bound its duration
+func (p *Plex) AddLabelToMediaContext(ctx context.Context, mediaType, sectionID, id, label, locked string) (bool, error) {
 
 	query := fmt.Sprintf("%s/library/sections/%s/all", p.URL, sectionID)
 
@@ -1215,7 +1328,7 @@ func (p *Plex) AddLabelToMedia(mediaType, sectionID, id, label, locked string) (
 
 	query = parsedQuery.String()
 
-	resp, err := p.put(query, nil, p.Headers)
+	resp, err := p.put(ctx, query, nil, p.Headers)
 
 	if err != nil {
 		return false, err
@@ -1223,11 +1336,20 @@ func (p *Plex) AddLabelToMedia(mediaType, sectionID, id, label, locked string) (
 
 	defer resp.Body.Close()
 
-	return resp.StatusCode == http.StatusOK, nil
+	if resp.StatusCode != http.StatusOK {
+		return false, newAPIError(resp)
+	}
+
+	return true, nil
 }
 
 // RemoveLabelFromMedia to remove a label from a piece of media Requires a Plex Pass.
 func (p *Plex) RemoveLabelFromMedia(mediaType, sectionID, id, label, locked string) (bool, error) {
+	return p.RemoveLabelFromMediaContext(context.Background(), mediaType, sectionID, id, label, locked)
+}
+
+// RemoveLabelFromMediaContext is like RemoveLabelFromMedia but uses ctx to cancel the request or bound its duration
+func (p *Plex) RemoveLabelFromMediaContext(ctx context.Context, mediaType, sectionID, id, label, locked string) (bool, error) {
 
 	query := fmt.Sprintf("%s/library/sections/%s/all", p.URL, sectionID)
 
@@ -1248,7 +1370,7 @@ func (p *Plex) RemoveLabelFromMedia(mediaType, sectionID, id, label, locked stri
 
 	query = parsedQuery.String()
 
-	resp, err := p.put(query, nil, p.Headers)
+	resp, err := p.put(ctx, query, nil, p.Headers)
 
 	if err != nil {
 		return false, err
@@ -1256,16 +1378,25 @@ func (p *Plex) RemoveLabelFromMedia(mediaType, sectionID, id, label, locked stri
 
 	defer resp.Body.Close()
 
-	return resp.StatusCode == http.StatusOK, nil
+	if resp.StatusCode != http.StatusOK {
+		return false, newAPIError(resp)
+	}
+
+	return true, nil
 }
 
 // GetSessions of devices currently consuming media
 func (p *Plex) GetSessions() (CurrentSessions, error) {
+	return p.GetSessionsContext(context.Background())
+}
+
+// GetSessionsContext is like GetSessions but uses ctx to cancel the request or bound its duration
+func (p *Plex) GetSessionsContext(ctx context.Context) (CurrentSessions, error) {
 	newHeaders := p.Headers
 
 	query := fmt.Sprintf("%s/status/sessions", p.URL)
 
-	resp, err := p.get(query, newHeaders)
+	resp, err := p.get(ctx, query, newHeaders)
 
 	if err != nil {
 		return CurrentSessions{}, err
@@ -1274,7 +1405,7 @@ func (p *Plex) GetSessions() (CurrentSessions, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return CurrentSessions{}, errors.New(resp.Status)
+		return CurrentSessions{}, newAPIError(resp)
 	}
 
 	var result CurrentSessions
@@ -1288,6 +1419,11 @@ func (p *Plex) GetSessions() (CurrentSessions, error) {
 
 // TerminateSession will end a streaming session - plex pass feature
 func (p *Plex) TerminateSession(sessionID string, reason string) error {
+	return p.TerminateSessionContext(context.Background(), sessionID, reason)
+}
+
+// TerminateSessionContext is like TerminateSession but uses ctx to cancel the request or bound its duration
+func (p *Plex) TerminateSessionContext(ctx context.Context, sessionID string, reason string) error {
 	if reason == "" {
 		reason = "The server owner has ended the stream"
 	}
@@ -1300,7 +1436,7 @@ func (p *Plex) TerminateSession(sessionID string, reason string) error {
 	newHeaders := p.Headers
 	newHeaders.Accept = applicationXml
 
-	resp, err := p.get(query, newHeaders)
+	resp, err := p.get(ctx, query, newHeaders)
 
 	if err != nil {
 		return err
@@ -1309,7 +1445,7 @@ func (p *Plex) TerminateSession(sessionID string, reason string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("%s", resp.Status)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -45,3 +45,9 @@ const testMetadata = `
       </Directory>
   </MediaContainer>
 `
+
+// recorded from GET /library/sections/1/collections
+const testCollections = `{"MediaContainer":{"size":2,"allowSync":false,"art":"/:/resources/movie-fanart.jpg","identifier":"com.plexapp.plugins.library","librarySectionID":1,"librarySectionTitle":"Movies","librarySectionUUID":"2f1a6c41-6c3f-4c9e-9a1f-1d5f0b3e1a2b","mediaTagPrefix":"/system/bundle/media/flags/","mediaTagVersion":1634589723,"thumb":"/:/resources/movie.png","title1":"Movies","viewGroup":"secondary","viewMode":65592,"Metadata":[{"ratingKey":"5001","key":"/library/collections/5001/children","guid":"collection://0a6b7ed0-4d59-4a4a-9d0f-8f1f3c3e8d11","type":"collection","title":"Alien Collection","librarySectionID":1,"librarySectionTitle":"Movies","librarySectionKey":"/library/sections/1","subtype":"movie","contentRating":"R","summary":"","index":5001,"thumb":"/library/collections/5001/composite/1634600000","addedAt":1634590000,"updatedAt":1634600000,"childCount":"4","collectionMode":"2","collectionSort":"0","maxYear":"1997","minYear":"1979"},{"ratingKey":"5002","key":"/library/collections/5002/children","guid":"collection://6c1e0d9e-1f3b-4e0b-bf6c-5e0a9e6d2c44","type":"collection","title":"Unwatched 90s","librarySectionID":1,"librarySectionTitle":"Movies","librarySectionKey":"/library/sections/1","subtype":"movie","summary":"","index":5002,"smart":"1","content":"/library/sections/1/all?type=1&year>>=1989&year<<=2000&unwatched=1","thumb":"/library/collections/5002/composite/1634600100","addedAt":1634590100,"updatedAt":1634600100,"childCount":"37"}]}}`
+
+// recorded from POST /library/collections
+const testCreatedCollection = `{"MediaContainer":{"size":1,"Metadata":[{"ratingKey":"5003","key":"/library/collections/5003/children","guid":"collection://9b8d2f7e-3a55-4c3c-8a0e-2d7f6a1c9e55","type":"collection","title":"Heist Movies","librarySectionID":1,"librarySectionTitle":"Movies","subtype":"movie","summary":"","index":5003,"addedAt":1634610000,"updatedAt":1634610000,"childCount":"2"}]}}`
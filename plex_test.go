@@ -1,6 +1,7 @@
 package plex
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
@@ -44,7 +45,7 @@ func newTestServer(code int, body string) (*httptest.Server, *Plex) {
 	}
 
 	httpClient := http.Client{Transport: transport}
-	plex := &Plex{URL: server.URL, Token: "", HTTPClient: httpClient}
+	plex := &Plex{URL: server.URL, Token: "", HTTPClient: httpClient, PlexTVURL: server.URL}
 
 	return server, plex
 }
@@ -220,3 +221,15 @@ func TestPlex_RemoveInvitedFriend(t *testing.T) {
 		t.Errorf("success: %v, error: %v", success, err)
 	}
 }
+
+func TestGetSessionsContextCanceled(t *testing.T) {
+	server, _plex := newTestServer(200, "")
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := _plex.GetSessionsContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got: %v", err)
+	}
+}
@@ -3,10 +3,9 @@ package plex
 // I'll slowly migrate plex.tv related functions to this file
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
-	"errors"
-	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -14,8 +13,8 @@ import (
 
 // ErrorResponse contains a code and an error message
 type ErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int    `json:"code" xml:"code,attr"`
+	Message string `json:"message" xml:"message,attr"`
 }
 
 // PinResponse holds information to successfully check a pin when linking an account
@@ -39,7 +38,12 @@ type PinResponse struct {
 }
 
 // RequestPIN will retrieve a code (valid for 15 minutes) from plex.tv to link an app to your plex account
-func RequestPIN(requestHeaders headers) (PinResponse, error) {
+func RequestPIN(requestHeaders headers, opts ...Option) (PinResponse, error) {
+	return RequestPINContext(context.Background(), requestHeaders, opts...)
+}
+
+// RequestPINContext is like RequestPIN but uses ctx to cancel the request or bound its duration
+func RequestPINContext(ctx context.Context, requestHeaders headers, opts ...Option) (PinResponse, error) {
 	endpoint := "/api/v2/pins.json"
 
 	// POST request and returns a 201 status code
@@ -58,7 +62,9 @@ func RequestPIN(requestHeaders headers) (PinResponse, error) {
 		requestHeaders = defaultHeaders()
 	}
 
-	resp, err := post(plexURL+endpoint, nil, requestHeaders)
+	p := newAnonymousClient(opts...)
+
+	resp, err := p.post(ctx, p.plexTVURL()+endpoint, nil, requestHeaders)
 
 	if err != nil {
 		return pinInformation, err
@@ -67,7 +73,7 @@ func RequestPIN(requestHeaders headers) (PinResponse, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		return pinInformation, errors.New(resp.Status)
+		return pinInformation, newAPIError(resp)
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&pinInformation); err != nil {
@@ -80,7 +86,12 @@ func RequestPIN(requestHeaders headers) (PinResponse, error) {
 // CheckPIN will return information related to the pin such as the auth token if your code has been approved.
 // will return an error if code expired or still not linked
 // clientIdentifier must be the same when requesting a pin
-func CheckPIN(id int, clientIdentifier string) (PinResponse, error) {
+func CheckPIN(id int, clientIdentifier string, opts ...Option) (PinResponse, error) {
+	return CheckPINContext(context.Background(), id, clientIdentifier, opts...)
+}
+
+// CheckPINContext is like CheckPIN but uses ctx to cancel the request or bound its duration
+func CheckPINContext(ctx context.Context, id int, clientIdentifier string, opts ...Option) (PinResponse, error) {
 	endpoint := "/api/v2/pins/"
 
 	endpoint = endpoint + strconv.Itoa(id) + ".json"
@@ -91,7 +102,9 @@ func CheckPIN(id int, clientIdentifier string) (PinResponse, error) {
 		headers.ClientIdentifier = clientIdentifier
 	}
 
-	resp, err := get(plexURL+endpoint, headers)
+	p := newAnonymousClient(opts...)
+
+	resp, err := p.get(ctx, p.plexTVURL()+endpoint, headers)
 
 	if err != nil {
 		return PinResponse{}, err
@@ -99,20 +112,31 @@ func CheckPIN(id int, clientIdentifier string) (PinResponse, error) {
 
 	defer resp.Body.Close()
 
+	// code doesn't exist or expired
+	if resp.StatusCode != http.StatusOK {
+		return PinResponse{}, newAPIError(resp)
+	}
+
 	var pinInformation PinResponse
 
 	if err := json.NewDecoder(resp.Body).Decode(&pinInformation); err != nil {
 		return pinInformation, err
 	}
 
-	// code doesn't exist or expired
 	if len(pinInformation.Errors) > 0 {
-		return pinInformation, errors.New(pinInformation.Errors[0].Message)
+		return pinInformation, &APIError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Method:     http.MethodGet,
+			Endpoint:   endpointOf(resp.Request),
+			Code:       pinInformation.Errors[0].Code,
+			Message:    pinInformation.Errors[0].Message,
+		}
 	}
 
 	// we are not authorized yet
 	if pinInformation.AuthToken == "" {
-		return pinInformation, errors.New(ErrorPINNotAuthorized)
+		return pinInformation, ErrPINNotAuthorized
 	}
 
 	// we are authorized! Yay!
@@ -121,6 +145,11 @@ func CheckPIN(id int, clientIdentifier string) (PinResponse, error) {
 
 // LinkAccount allows you to authorize an app via a 4 character pin. returns nil on success
 func (p Plex) LinkAccount(code string) error {
+	return p.LinkAccountContext(context.Background(), code)
+}
+
+// LinkAccountContext is like LinkAccount but uses ctx to cancel the request or bound its duration
+func (p Plex) LinkAccountContext(ctx context.Context, code string) error {
 	endpoint := "/api/v2/pins/link.json"
 
 	body := url.Values{
@@ -132,7 +161,7 @@ func (p Plex) LinkAccount(code string) error {
 	headers.ContentType = "application/x-www-form-urlencoded"
 
 	// PUT request with 'code: <4-character-pin>' in the body
-	resp, err := p.put(plexURL+endpoint, []byte(body.Encode()), headers)
+	resp, err := p.put(ctx, p.plexTVURL()+endpoint, []byte(body.Encode()), headers)
 
 	if err != nil {
 		return err
@@ -150,30 +179,19 @@ func (p Plex) LinkAccount(code string) error {
 
 	// should return 204 for success
 	if resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf(ErrorLinkAccount, resp.Status)
+		return newAPIError(resp)
 	}
 
 	return nil
 }
 
-type webhookErr struct {
-	Err []struct {
-		Code    int    `json:"code"`
-		Message string `json:"message"`
-		Status  int    `json:"status"`
-	} `json:"errors"`
-}
-
-func (w webhookErr) Error() string {
-	if len(w.Err) == 0 {
-		return ""
-	}
-
-	return w.Err[0].Message
-}
-
 // GetWebhooks fetches all webhooks - requires plex pass
 func (p Plex) GetWebhooks() ([]string, error) {
+	return p.GetWebhooksContext(context.Background())
+}
+
+// GetWebhooksContext is like GetWebhooks but uses ctx to cancel the request or bound its duration
+func (p Plex) GetWebhooksContext(ctx context.Context) ([]string, error) {
 	type Hooks struct {
 		URL string `json:"url"`
 	}
@@ -182,7 +200,7 @@ func (p Plex) GetWebhooks() ([]string, error) {
 
 	endpoint := "/api/v2/user/webhooks"
 
-	resp, err := p.get(plexURL+endpoint, p.Headers)
+	resp, err := p.get(ctx, p.plexTVURL()+endpoint, p.Headers)
 
 	if err != nil {
 		return webhooks, err
@@ -190,16 +208,8 @@ func (p Plex) GetWebhooks() ([]string, error) {
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= http.StatusBadRequest && resp.StatusCode < http.StatusInternalServerError {
-		var webhookErr webhookErr
-
-		if err := json.NewDecoder(resp.Body).Decode(&webhookErr); err != nil {
-			return webhooks, err
-		}
-
-		return webhooks, fmt.Errorf(ErrorWebhook, webhookErr.Error())
-	} else if resp.StatusCode != http.StatusOK {
-		return webhooks, fmt.Errorf(ErrorWebhook, resp.Status)
+	if resp.StatusCode != http.StatusOK {
+		return webhooks, newAPIError(resp)
 	}
 
 	var hook []Hooks
@@ -217,8 +227,13 @@ func (p Plex) GetWebhooks() ([]string, error) {
 
 // AddWebhook creates a new webhook for your plex server to send metadata - requires plex pass
 func (p Plex) AddWebhook(webhook string) error {
+	return p.AddWebhookContext(context.Background(), webhook)
+}
+
+// AddWebhookContext is like AddWebhook but uses ctx to cancel the request or bound its duration
+func (p Plex) AddWebhookContext(ctx context.Context, webhook string) error {
 	// get current webhooks and append ours to it
-	currentWebhooks, err := p.GetWebhooks()
+	currentWebhooks, err := p.GetWebhooksContext(ctx)
 
 	if err != nil {
 		return err
@@ -226,12 +241,17 @@ func (p Plex) AddWebhook(webhook string) error {
 
 	currentWebhooks = append(currentWebhooks, webhook)
 
-	return p.SetWebhooks(currentWebhooks)
+	return p.SetWebhooksContext(ctx, currentWebhooks)
 }
 
 // SetWebhooks will set your webhooks to whatever you pass as an argument
 // webhooks with a length of 0 will remove all webhooks
 func (p Plex) SetWebhooks(webhooks []string) error {
+	return p.SetWebhooksContext(context.Background(), webhooks)
+}
+
+// SetWebhooksContext is like SetWebhooks but uses ctx to cancel the request or bound its duration
+func (p Plex) SetWebhooksContext(ctx context.Context, webhooks []string) error {
 	endpoint := "/api/v2/user/webhooks"
 
 	body := url.Values{}
@@ -248,7 +268,7 @@ func (p Plex) SetWebhooks(webhooks []string) error {
 
 	headers.ContentType = "application/x-www-form-urlencoded"
 
-	resp, err := p.post(plexURL+endpoint, []byte(body.Encode()), headers)
+	resp, err := p.post(ctx, p.plexTVURL()+endpoint, []byte(body.Encode()), headers)
 
 	if err != nil {
 		return err
@@ -257,7 +277,7 @@ func (p Plex) SetWebhooks(webhooks []string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		return errors.New(ErrorFailedToSetWebhook)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -265,11 +285,16 @@ func (p Plex) SetWebhooks(webhooks []string) error {
 
 // MyAccount gets account info (i.e. plex pass, servers, username, etc) from plex tv
 func (p Plex) MyAccount() (UserPlexTV, error) {
+	return p.MyAccountContext(context.Background())
+}
+
+// MyAccountContext is like MyAccount but uses ctx to cancel the request or bound its duration
+func (p Plex) MyAccountContext(ctx context.Context) (UserPlexTV, error) {
 	endpoint := "/users/account"
 
 	var account UserPlexTV
 
-	resp, err := p.get(plexURL+endpoint, p.Headers)
+	resp, err := p.get(ctx, p.plexTVURL()+endpoint, p.Headers)
 
 	if err != nil {
 		return account, err
@@ -277,10 +302,9 @@ func (p Plex) MyAccount() (UserPlexTV, error) {
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusUnprocessableEntity {
-		return account, errors.New(ErrorInvalidToken)
-	} else if resp.StatusCode != http.StatusOK {
-		return account, errors.New(resp.Status)
+	// plex.tv replies with 422 for an invalid token
+	if resp.StatusCode != http.StatusOK {
+		return account, newAPIError(resp)
 	}
 
 	if err := xml.NewDecoder(resp.Body).Decode(&account); err != nil {
@@ -0,0 +1,71 @@
+package plex
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestAndCheckPINWithPlexTVURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/pins.json":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id":1234,"code":"ABCD","clientIdentifier":"go-plex-client-v0.0.1"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/pins/1234.json":
+			fmt.Fprint(w, `{"id":1234,"code":"ABCD","authToken":"abc123"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	pin, err := RequestPIN(defaultHeaders(), WithPlexTVURL(server.URL))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pin.Code != "ABCD" {
+		t.Errorf("expected pin code ABCD, got %s", pin.Code)
+	}
+
+	pin, err = CheckPIN(pin.ID, pin.ClientIdentifier, WithPlexTVURL(server.URL+"/"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pin.AuthToken != "abc123" {
+		t.Errorf("expected auth token abc123, got %s", pin.AuthToken)
+	}
+}
+
+func TestGetWebhooksWithPlexTVURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/user/webhooks" || r.Header.Get("X-Plex-Token") != "abc123" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		fmt.Fprint(w, `[{"url":"http://localhost:8080/hook"}]`)
+	}))
+	defer server.Close()
+
+	p, err := New("", "abc123", WithPlexTVURL(server.URL))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hooks, err := p.GetWebhooks()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hooks) != 1 || hooks[0] != "http://localhost:8080/hook" {
+		t.Errorf("unexpected webhooks: %v", hooks)
+	}
+}
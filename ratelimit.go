@@ -0,0 +1,208 @@
+package plex
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// minRateLimitBurst keeps small limits from splitting reads into tiny chunks
+const minRateLimitBurst = 32 << 10
+
+// RateLimiter is a token bucket limiting the bytes per second of downloads. A limiter can be
+// shared by several downloads to cap their combined bandwidth
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter allowing bytesPerSecond with bursts of up to one second of traffic.
+// The rate must be positive
+func NewRateLimiter(bytesPerSecond int64) (*RateLimiter, error) {
+	if bytesPerSecond <= 0 {
+		return nil, fmt.Errorf("invalid rate limit %d, expected a positive number of bytes per second", bytesPerSecond)
+	}
+
+	return newRateLimiter(bytesPerSecond), nil
+}
+
+// newRateLimiter creates a limiter for a rate that is known to be positive
+func newRateLimiter(bytesPerSecond int64) *RateLimiter {
+	burst := int(bytesPerSecond)
+
+	if burst < minRateLimitBurst {
+		burst = minRateLimitBurst
+	}
+
+	return &RateLimiter{
+		rate:   float64(bytesPerSecond),
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// WaitN blocks until n bytes may be transferred or ctx is done. n must not exceed the burst of the limiter
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+
+	now := time.Now()
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	l.last = now
+
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+
+	// take the tokens now and wait for the debt to be paid, so waiters are served in order
+	l.tokens -= float64(n)
+
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rateLimitedReader reads at the pace of every limiter
+type rateLimitedReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	limiters []*RateLimiter
+}
+
+func (r *rateLimitedReader) Read(b []byte) (int, error) {
+	for _, limiter := range r.limiters {
+		if len(b) > limiter.burst {
+			b = b[:limiter.burst]
+		}
+	}
+
+	n, err := r.reader.Read(b)
+
+	for _, limiter := range r.limiters {
+		if waitErr := limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
+
+// TimeWindow is a daily period of local time, i.e. from 01:00 to 07:00. A window whose end
+// is before its start runs past midnight and one whose end equals its start lasts all day
+type TimeWindow struct {
+	// Start and End are offsets from midnight
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseTimeWindow parses a window written as "01:00-07:00"
+func ParseTimeWindow(window string) (TimeWindow, error) {
+	var startHour, startMinute, endHour, endMinute int
+
+	if _, err := fmt.Sscanf(window, "%d:%d-%d:%d", &startHour, &startMinute, &endHour, &endMinute); err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q, expected i.e. 01:00-07:00", window)
+	}
+
+	for _, v := range [][2]int{{startHour, startMinute}, {endHour, endMinute}} {
+		if v[0] < 0 || v[0] > 24 || v[1] < 0 || v[1] > 59 || (v[0] == 24 && v[1] != 0) {
+			return TimeWindow{}, fmt.Errorf("invalid time window %q", window)
+		}
+	}
+
+	return TimeWindow{
+		Start: time.Duration(startHour)*time.Hour + time.Duration(startMinute)*time.Minute,
+		End:   time.Duration(endHour)*time.Hour + time.Duration(endMinute)*time.Minute,
+	}, nil
+}
+
+// Contains reports whether t is inside the window
+func (w TimeWindow) Contains(t time.Time) bool {
+	offset := sinceMidnight(t)
+
+	if w.Start == w.End {
+		return true
+	}
+
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+
+	return offset >= w.Start || offset < w.End
+}
+
+// NextStart returns t if it is inside the window, otherwise when the window opens next
+func (w TimeWindow) NextStart(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+
+	start := midnight(t).Add(w.Start)
+
+	if !start.After(t) {
+		start = midnight(t.AddDate(0, 0, 1)).Add(w.Start)
+	}
+
+	return start
+}
+
+// NextEnd returns when the window that contains t closes
+func (w TimeWindow) NextEnd(t time.Time) time.Time {
+	end := midnight(t).Add(w.End)
+
+	if !end.After(t) {
+		end = midnight(t.AddDate(0, 0, 1)).Add(w.End)
+	}
+
+	return end
+}
+
+// wait blocks until the window opens or ctx is done
+func (w TimeWindow) wait(ctx context.Context) error {
+	now := time.Now()
+
+	delay := w.NextStart(now).Sub(now)
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func midnight(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return t.Sub(midnight(t))
+}
@@ -0,0 +1,203 @@
+package plex
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimeWindow(t *testing.T) {
+	window, err := ParseTimeWindow("23:00-07:00")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2020, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	testCases := []struct {
+		now       time.Time
+		contains  bool
+		nextStart time.Time
+	}{
+		{at(23, 30), true, at(23, 30)},
+		{at(3, 0), true, at(3, 0)},
+		{at(7, 0), false, at(23, 0)},
+		{at(12, 0), false, at(23, 0)},
+	}
+
+	for _, tc := range testCases {
+		if window.Contains(tc.now) != tc.contains {
+			t.Errorf("%s: expected contains to be %v", tc.now.Format("15:04"), tc.contains)
+		}
+
+		if next := window.NextStart(tc.now); !next.Equal(tc.nextStart) {
+			t.Errorf("%s: expected the window to open at %s, got %s", tc.now.Format("15:04"), tc.nextStart, next)
+		}
+	}
+
+	if end := window.NextEnd(at(23, 30)); !end.Equal(at(7, 0).AddDate(0, 0, 1)) {
+		t.Errorf("expected the window to close the next morning, got %s", end)
+	}
+
+	for _, invalid := range []string{"", "1am-7am", "25:00-07:00", "01:00-07:60"} {
+		if _, err := ParseTimeWindow(invalid); err == nil {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	for _, rate := range []int64{0, -1} {
+		if _, err := NewRateLimiter(rate); err == nil {
+			t.Errorf("expected a rate of %d to be rejected", rate)
+		}
+	}
+
+	limiter, err := NewRateLimiter(128 << 10)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+
+	// the burst passes right away, the rest waits for the bucket to refill
+	for i := 0; i < 5; i++ {
+		if err := limiter.WaitN(context.Background(), 32<<10); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("expected 160KB at 128KB/s to take at least 250ms, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancel()
+
+	if err := limiter.WaitN(ctx, 128<<10); err != context.Canceled {
+		t.Errorf("expected a canceled wait, got %v", err)
+	}
+}
+
+func TestDownloadAllRateLimit(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), 160<<10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file.mkv", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "plex-download")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	items := []Metadata{{Media: []Media{{Part: []Part{{Key: "/library/parts/1/file.mkv", File: "file.mkv", Size: len(content)}}}}}}
+
+	limiter, err := NewRateLimiter(128 << 10)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+
+	if _, err := p.DownloadAll(items, dir, DownloadOptions{RateLimiter: limiter}); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("expected the download to be throttled, took %s", elapsed)
+	}
+}
+
+func TestDownloadAllWindowResume(t *testing.T) {
+	now := sinceMidnight(time.Now())
+
+	if now < time.Second || now > 24*time.Hour-5*time.Second {
+		t.Skip("the window can not wrap around midnight")
+	}
+
+	content := bytes.Repeat([]byte("x"), 160<<10)
+
+	var (
+		mu     sync.Mutex
+		ranges []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+
+		http.ServeContent(w, r, "file.mkv", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "plex-download")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	items := []Metadata{{Media: []Media{{Part: []Part{{Key: "/library/parts/1/file.mkv", File: "file.mkv", Size: len(content)}}}}}}
+
+	// open all day but for a short break, which the throttled download runs into
+	window := TimeWindow{Start: now + 500*time.Millisecond, End: now + 300*time.Millisecond}
+
+	results, err := p.DownloadAll(items, dir, DownloadOptions{PerFileRate: 64 << 10, Window: &window})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(ranges) != 2 || ranges[0] != "" || !strings.HasPrefix(ranges[1], "bytes=") {
+		t.Errorf("expected the download to be resumed once the window reopened, got ranges %q", ranges)
+	}
+
+	if !results[0].Resumed || results[0].Size != int64(len(content)) {
+		t.Errorf("expected a complete resumed file, got %+v", results[0])
+	}
+
+	data, err := ioutil.ReadFile(results[0].Path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, content) {
+		t.Error("resumed file has unexpected content")
+	}
+}
@@ -0,0 +1,170 @@
+package plex
+
+import (
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// RetryPolicy retries idempotent (GET and HEAD) requests that failed to
+// connect or were answered with 429 or a 5xx status code
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first request.
+	// A value of 1 or less disables retries
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry and doubles on each retry. Defaults to 500ms
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff. Defaults to 30s. A Retry-After
+	// header sent by the server takes precedence
+	MaxDelay time.Duration
+	// OnRetry is called before waiting for the next attempt
+	OnRetry func(event RetryEvent)
+}
+
+// RetryEvent describes a failed attempt that is about to be retried
+type RetryEvent struct {
+	// Attempt is the failed attempt, starting at 1
+	Attempt int
+	Request *http.Request
+	// StatusCode of the failed attempt; 0 when no response was received
+	StatusCode int
+	// Err is the error of the failed attempt, if any
+	Err error
+	// Delay is how long until the next attempt
+	Delay time.Duration
+}
+
+// WithRetry enables retries of idempotent requests
+func WithRetry(policy RetryPolicy) Option {
+	return func(p *Plex) {
+		p.Retry = &policy
+	}
+}
+
+// retry wraps handler to retry idempotent requests according to the policy
+func (r RetryPolicy) retry(handler RequestHandler) RequestHandler {
+	return func(req *http.Request) (*http.Response, error) {
+		if r.MaxAttempts <= 1 || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+			return handler(req)
+		}
+
+		for attempt := 1; ; attempt++ {
+			resp, err := handler(req)
+
+			if attempt >= r.MaxAttempts || !shouldRetry(resp, err) || req.Context().Err() != nil {
+				return resp, err
+			}
+
+			event := RetryEvent{
+				Attempt: attempt,
+				Request: req,
+				Err:     err,
+				Delay:   r.backoff(attempt),
+			}
+
+			if err == nil {
+				event.StatusCode = resp.StatusCode
+
+				if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+					event.Delay = retryAfter
+				}
+
+				// allow the connection to be reused
+				io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxErrorBodyLength))
+				resp.Body.Close()
+			}
+
+			if r.OnRetry != nil {
+				r.OnRetry(event)
+			}
+
+			timer := time.NewTimer(event.Delay)
+
+			select {
+			case <-timer.C:
+			case <-req.Context().Done():
+				timer.Stop()
+
+				return nil, req.Context().Err()
+			}
+		}
+	}
+}
+
+// backoff returns the exponential delay with jitter before the next attempt
+func (r RetryPolicy) backoff(attempt int) time.Duration {
+	base := r.BaseDelay
+
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+
+	max := r.MaxDelay
+
+	if max <= 0 {
+		max = defaultRetryMaxDelay
+	}
+
+	delay := base
+
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+
+	if delay > max {
+		delay = max
+	}
+
+	// keep half of the delay and randomize the rest
+	half := delay / 2
+
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header in either seconds or http date format
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+
+		if delay < 0 {
+			delay = 0
+		}
+
+		return delay, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,102 @@
+package plex
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryIdempotentRequests(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		if requests == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		fmt.Fprint(w, `{"MediaContainer":{"size":0}}`)
+	}))
+	defer server.Close()
+
+	var events []RetryEvent
+
+	p, err := New(server.URL, "abc123", WithRetry(RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		OnRetry: func(event RetryEvent) {
+			events = append(events, event)
+		},
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.GetSessions(); err != nil {
+		t.Fatal(err)
+	}
+
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 retry events, got %d", len(events))
+	}
+
+	if events[0].StatusCode != http.StatusTooManyRequests || events[0].Delay != 0 {
+		t.Errorf("expected Retry-After to be honored: %+v", events[0])
+	}
+
+	if events[1].Attempt != 2 || events[1].StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("unexpected retry event: %+v", events[1])
+	}
+}
+
+func TestRetrySkipsNonIdempotentRequests(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123", WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.DeleteMediaByID("1"); err == nil {
+		t.Error("expected an error")
+	}
+
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for attempt, max := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		max *= time.Millisecond
+		delay := policy.backoff(attempt + 1)
+
+		if delay < max/2 || delay > max {
+			t.Errorf("attempt %d: expected a delay between %s and %s, got %s", attempt+1, max/2, max, delay)
+		}
+	}
+}
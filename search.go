@@ -1,10 +1,18 @@
 package plex
 
-import "regexp"
+import (
+	"context"
+	"regexp"
+)
 
 // SearchPlex searches just like Search, but omits the last 4 results which are not relevant
 func (p *Plex) SearchPlex(title string) (SearchResults, error) {
-	results, err := p.Search(title)
+	return p.SearchPlexContext(context.Background(), title)
+}
+
+// SearchPlexContext is like SearchPlex but uses ctx to cancel the request or bound its duration
+func (p *Plex) SearchPlexContext(ctx context.Context, title string) (SearchResults, error) {
+	results, err := p.SearchContext(ctx, title)
 
 	if err != nil {
 		return SearchResults{}, err
@@ -0,0 +1,436 @@
+package plex
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultReconcileInterval = 30 * time.Second
+
+// SessionEventType is the kind of change reported by a SessionTracker
+type SessionEventType string
+
+const (
+	// SessionStart is emitted when a session is first seen
+	SessionStart SessionEventType = "start"
+	// SessionPause is emitted when a session is paused. Duration is the time it was playing
+	SessionPause SessionEventType = "pause"
+	// SessionResume is emitted when a paused or buffering session plays again. Duration is the time it was paused or buffering
+	SessionResume SessionEventType = "resume"
+	// SessionBuffering is emitted when a session starts buffering. Duration is the time spent in the previous state
+	SessionBuffering SessionEventType = "buffering"
+	// SessionProgress is emitted when a playing session reports a new view offset. Duration is the time since the previous update
+	SessionProgress SessionEventType = "progress"
+	// SessionStop is emitted when a session ends. Duration is the time since it started
+	SessionStop SessionEventType = "stop"
+)
+
+// Session states as reported by the server
+const (
+	SessionStatePlaying   = "playing"
+	SessionStatePaused    = "paused"
+	SessionStateBuffering = "buffering"
+	SessionStateStopped   = "stopped"
+)
+
+// Transcode decisions of a TrackedSession
+const (
+	TranscodeDecisionDirectPlay   = "directplay"
+	TranscodeDecisionDirectStream = "copy"
+	TranscodeDecisionTranscode    = "transcode"
+)
+
+// TrackedSession is the state of an active playback session
+type TrackedSession struct {
+	SessionKey string
+	RatingKey  string
+	// State is one of the SessionState constants
+	State      string
+	ViewOffset time.Duration
+	User       User
+	Player     Player
+	// Media is the metadata of the item played, as reported by /status/sessions
+	Media Metadata
+	// TranscodeDecision is one of the TranscodeDecision constants
+	TranscodeDecision string
+	StartedAt         time.Time
+	StateChangedAt    time.Time
+	UpdatedAt         time.Time
+	// PlayingDuration is the total time spent playing, up to StateChangedAt
+	PlayingDuration time.Duration
+	// PausedDuration is the total time spent paused or buffering, up to StateChangedAt
+	PausedDuration time.Duration
+}
+
+// SessionEvent is a change of a session reported by a SessionTracker
+type SessionEvent struct {
+	Type    SessionEventType
+	Session TrackedSession
+	// Duration depends on Type, see the SessionEventType constants
+	Duration time.Duration
+	At       time.Time
+}
+
+// SessionTrackerOptions configures a SessionTracker
+type SessionTrackerOptions struct {
+	// OnEvent receives every session change. Events are emitted one at a time
+	OnEvent func(event SessionEvent)
+	// OnError receives errors of reconciliations and session lookups
+	OnError func(err error)
+	// ReconcileInterval is how often the tracked sessions are compared with /status/sessions. Defaults to 30s
+	ReconcileInterval time.Duration
+	// Subscribe configures the notification subscription
+	Subscribe SubscribeOptions
+}
+
+// SessionTracker keeps an in-memory model of the active playback sessions of your server.
+// It follows play session notifications and periodically reconciles with /status/sessions
+// so sessions missed by the websocket do not go stale.
+//
+//	tracker := plexConnection.NewSessionTracker(plex.SessionTrackerOptions{
+//		OnEvent: func(event plex.SessionEvent) {
+//			fmt.Println(event.Type, event.Session.User.Title, event.Session.Media.Title, event.Duration)
+//		},
+//	})
+//
+//	err := tracker.Run(ctx)
+type SessionTracker struct {
+	plex *Plex
+	opts SessionTrackerOptions
+	now  func() time.Time
+
+	// updateMu serializes updates so events are emitted in order
+	updateMu sync.Mutex
+	mu       sync.Mutex
+	sessions map[string]*TrackedSession
+}
+
+// NewSessionTracker creates a tracker of the sessions of your server. Call Run to start it
+func (p *Plex) NewSessionTracker(opts SessionTrackerOptions) *SessionTracker {
+	if opts.ReconcileInterval <= 0 {
+		opts.ReconcileInterval = defaultReconcileInterval
+	}
+
+	return &SessionTracker{
+		plex:     p,
+		opts:     opts,
+		now:      time.Now,
+		sessions: map[string]*TrackedSession{},
+	}
+}
+
+// Run subscribes to notifications and reconciles sessions until ctx is done.
+// It reconciles right away and after every reconnect. It returns nil once ctx is done
+func (t *SessionTracker) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reconcile := make(chan struct{}, 1)
+
+	requestReconcile := func() {
+		select {
+		case reconcile <- struct{}{}:
+		default:
+		}
+	}
+
+	events := NewNotificationEvents()
+
+	events.OnPlaySessionState(func(n []PlaySessionStateNotification) {
+		t.HandleNotification(ctx, n)
+	})
+
+	subscribeOpts := t.opts.Subscribe
+	onStateChange := subscribeOpts.OnStateChange
+
+	subscribeOpts.OnStateChange = func(state ConnectionState, err error) {
+		if state == StateConnected {
+			requestReconcile()
+		}
+
+		if onStateChange != nil {
+			onStateChange(state, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(t.opts.ReconcileInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := t.Reconcile(ctx); err != nil && ctx.Err() == nil {
+				t.error(err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-reconcile:
+			}
+		}
+	}()
+
+	err := t.plex.SubscribeToNotificationsContext(ctx, events, subscribeOpts)
+
+	cancel()
+	wg.Wait()
+
+	return err
+}
+
+// Sessions returns the active sessions ordered by start time
+func (t *SessionTracker) Sessions() []TrackedSession {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sessions := make([]TrackedSession, 0, len(t.sessions))
+
+	for _, session := range t.sessions {
+		sessions = append(sessions, *session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt.Before(sessions[j].StartedAt)
+	})
+
+	return sessions
+}
+
+// Session returns the active session of sessionKey
+func (t *SessionTracker) Session(sessionKey string) (TrackedSession, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	session, ok := t.sessions[sessionKey]
+
+	if !ok {
+		return TrackedSession{}, false
+	}
+
+	return *session, true
+}
+
+// HandleNotification applies play session notifications. Sessions that are not tracked yet
+// are looked up in /status/sessions so their user, player and media are known. Run calls it
+// for every notification; call it yourself when you feed the tracker from your own subscription
+func (t *SessionTracker) HandleNotification(ctx context.Context, notifications []PlaySessionStateNotification) {
+	t.updateMu.Lock()
+	defer t.updateMu.Unlock()
+
+	var current map[string]Metadata
+
+	for _, n := range notifications {
+		if n.SessionKey == "" {
+			continue
+		}
+
+		_, tracked := t.Session(n.SessionKey)
+
+		if !tracked && n.State != SessionStateStopped && current == nil {
+			sessions, err := t.plex.GetSessionsContext(ctx)
+
+			if err != nil {
+				t.error(err)
+			}
+
+			current = sessionsByKey(sessions)
+		}
+
+		media, ok := current[n.SessionKey]
+
+		if !ok {
+			media = Metadata{
+				Key:        n.Key,
+				RatingKey:  n.RatingKey,
+				SessionKey: n.SessionKey,
+			}
+		}
+
+		t.apply(media, n.State, time.Duration(n.ViewOffset)*time.Millisecond, ok)
+	}
+}
+
+// Reconcile compares the tracked sessions with /status/sessions. Missing sessions are started,
+// state changes are applied and sessions the server no longer reports are stopped
+func (t *SessionTracker) Reconcile(ctx context.Context) error {
+	// notifications wait for the fetch, a stop handled in between would be undone by the older snapshot
+	t.updateMu.Lock()
+	defer t.updateMu.Unlock()
+
+	sessions, err := t.plex.GetSessionsContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	current := sessionsByKey(sessions)
+
+	for _, media := range current {
+		t.apply(media, media.Player.State, time.Duration(media.ViewOffset)*time.Millisecond, true)
+	}
+
+	for _, session := range t.Sessions() {
+		if _, ok := current[session.SessionKey]; !ok {
+			t.apply(Metadata{SessionKey: session.SessionKey}, SessionStateStopped, session.ViewOffset, false)
+		}
+	}
+
+	return nil
+}
+
+// apply moves a session to state and emits the resulting events. When detailed is true
+// media holds the full session as reported by /status/sessions
+func (t *SessionTracker) apply(media Metadata, state string, offset time.Duration, detailed bool) {
+	now := t.now()
+
+	t.mu.Lock()
+
+	session, tracked := t.sessions[media.SessionKey]
+
+	if state == SessionStateStopped {
+		if !tracked {
+			t.mu.Unlock()
+			return
+		}
+
+		delete(t.sessions, media.SessionKey)
+
+		session.accumulate(now)
+		session.State = SessionStateStopped
+		session.UpdatedAt = now
+
+		if offset > 0 {
+			session.ViewOffset = offset
+		}
+
+		event := SessionEvent{Type: SessionStop, Session: *session, Duration: now.Sub(session.StartedAt), At: now}
+
+		t.mu.Unlock()
+
+		t.emit(event)
+
+		return
+	}
+
+	var events []SessionEvent
+
+	if state == "" {
+		state = SessionStatePlaying
+
+		if tracked {
+			state = session.State
+		}
+	}
+
+	if !tracked {
+		session = &TrackedSession{
+			SessionKey:     media.SessionKey,
+			State:          state,
+			StartedAt:      now,
+			StateChangedAt: now,
+		}
+
+		t.sessions[media.SessionKey] = session
+	}
+
+	if detailed || !tracked {
+		session.RatingKey = media.RatingKey
+		session.User = media.User
+		session.Player = media.Player
+		session.Media = media
+		session.TranscodeDecision = transcodeDecision(media.TranscodeSession)
+	}
+
+	previousOffset := session.ViewOffset
+	previousUpdate := session.UpdatedAt
+
+	session.ViewOffset = offset
+	session.UpdatedAt = now
+
+	switch {
+	case !tracked:
+		events = append(events, SessionEvent{Type: SessionStart, Session: *session, At: now})
+	case state != session.State:
+		elapsed := session.accumulate(now)
+
+		session.State = state
+		session.StateChangedAt = now
+
+		eventType := SessionResume
+
+		switch state {
+		case SessionStatePaused:
+			eventType = SessionPause
+		case SessionStateBuffering:
+			eventType = SessionBuffering
+		}
+
+		events = append(events, SessionEvent{Type: eventType, Session: *session, Duration: elapsed, At: now})
+	case state == SessionStatePlaying && offset != previousOffset:
+		events = append(events, SessionEvent{Type: SessionProgress, Session: *session, Duration: now.Sub(previousUpdate), At: now})
+	}
+
+	t.mu.Unlock()
+
+	for _, event := range events {
+		t.emit(event)
+	}
+}
+
+// accumulate adds the time spent in the current state to the session's totals and returns it
+func (s *TrackedSession) accumulate(now time.Time) time.Duration {
+	elapsed := now.Sub(s.StateChangedAt)
+
+	if s.State == SessionStatePlaying {
+		s.PlayingDuration += elapsed
+	} else {
+		s.PausedDuration += elapsed
+	}
+
+	return elapsed
+}
+
+func (t *SessionTracker) emit(event SessionEvent) {
+	if t.opts.OnEvent != nil {
+		t.opts.OnEvent(event)
+	}
+}
+
+func (t *SessionTracker) error(err error) {
+	if t.opts.OnError != nil {
+		t.opts.OnError(err)
+	}
+}
+
+func sessionsByKey(sessions CurrentSessions) map[string]Metadata {
+	result := map[string]Metadata{}
+
+	for _, media := range sessions.MediaContainer.Metadata {
+		if media.SessionKey != "" {
+			result[media.SessionKey] = media
+		}
+	}
+
+	return result
+}
+
+func transcodeDecision(session TranscodeSession) string {
+	if session.Key == "" {
+		return TranscodeDecisionDirectPlay
+	}
+
+	if session.VideoDecision == TranscodeDecisionTranscode || session.AudioDecision == TranscodeDecisionTranscode {
+		return TranscodeDecisionTranscode
+	}
+
+	return TranscodeDecisionDirectStream
+}
@@ -0,0 +1,250 @@
+package plex
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testSession = `{"MediaContainer":{"size":1,"Metadata":[{"ratingKey":"10","key":"/library/metadata/10","title":"Pilot","type":"episode","sessionKey":"42","viewOffset":60000,"User":{"id":"1","title":"walter"},"Player":{"machineIdentifier":"tv-1","title":"Living Room","state":"playing"},"TranscodeSession":{"key":"/transcode/sessions/abc","videoDecision":"transcode","audioDecision":"copy"}}]}}`
+
+func TestSessionTracker(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		response = testSession
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status/sessions" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.Header().Set("Content-Type", applicationJson)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []SessionEvent
+
+	tracker := p.NewSessionTracker(SessionTrackerOptions{
+		OnEvent: func(event SessionEvent) {
+			events = append(events, event)
+		},
+		OnError: func(err error) {
+			t.Error(err)
+		},
+	})
+
+	clock := time.Unix(0, 0)
+
+	tracker.now = func() time.Time { return clock }
+
+	notify := func(state string, offset int64) {
+		tracker.HandleNotification(context.Background(), []PlaySessionStateNotification{
+			{SessionKey: "42", RatingKey: "10", State: state, ViewOffset: offset},
+		})
+
+		clock = clock.Add(10 * time.Second)
+	}
+
+	notify(SessionStatePlaying, 60000)
+	notify(SessionStatePlaying, 70000)
+	notify(SessionStatePaused, 80000)
+	notify(SessionStatePlaying, 80000)
+	notify(SessionStateBuffering, 90000)
+
+	expected := []struct {
+		eventType SessionEventType
+		duration  time.Duration
+	}{
+		{SessionStart, 0},
+		{SessionProgress, 10 * time.Second},
+		{SessionPause, 20 * time.Second},
+		{SessionResume, 10 * time.Second},
+		{SessionBuffering, 10 * time.Second},
+	}
+
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %+v", len(expected), events)
+	}
+
+	for i, e := range expected {
+		if events[i].Type != e.eventType || events[i].Duration != e.duration {
+			t.Errorf("event %d: expected %s after %s, got %s after %s", i, e.eventType, e.duration, events[i].Type, events[i].Duration)
+		}
+	}
+
+	session, ok := tracker.Session("42")
+
+	if !ok {
+		t.Fatal("expected session 42 to be tracked")
+	}
+
+	if session.User.Title != "walter" || session.Player.Title != "Living Room" || session.Media.Title != "Pilot" {
+		t.Errorf("expected session details from /status/sessions, got %+v", session)
+	}
+
+	if session.TranscodeDecision != TranscodeDecisionTranscode {
+		t.Errorf("expected transcode decision %s, got %s", TranscodeDecisionTranscode, session.TranscodeDecision)
+	}
+
+	if session.PlayingDuration != 30*time.Second || session.PausedDuration != 10*time.Second {
+		t.Errorf("expected 30s playing and 10s paused, got %s and %s", session.PlayingDuration, session.PausedDuration)
+	}
+
+	// the stop notification was missed so reconciling ends the session
+	mu.Lock()
+	response = `{"MediaContainer":{"size":0}}`
+	mu.Unlock()
+
+	events = nil
+
+	if err := tracker.Reconcile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || events[0].Type != SessionStop || events[0].Duration != 50*time.Second {
+		t.Fatalf("expected a stop event after 50s, got %+v", events)
+	}
+
+	if sessions := tracker.Sessions(); len(sessions) != 0 {
+		t.Errorf("expected no sessions, got %+v", sessions)
+	}
+}
+
+func TestSessionTrackerReconcileStartsMissedSessions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", applicationJson)
+		w.Write([]byte(testSession))
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []SessionEvent
+
+	tracker := p.NewSessionTracker(SessionTrackerOptions{
+		OnEvent: func(event SessionEvent) {
+			events = append(events, event)
+		},
+	})
+
+	if err := tracker.Reconcile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || events[0].Type != SessionStart {
+		t.Fatalf("expected a start event, got %+v", events)
+	}
+
+	if events[0].Session.ViewOffset != time.Minute || events[0].Session.State != SessionStatePlaying {
+		t.Errorf("unexpected session: %+v", events[0].Session)
+	}
+}
+
+func TestSessionTrackerReconcileDuringStop(t *testing.T) {
+	fetching := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	var blocking int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the server still reports the session that is about to stop
+		if atomic.LoadInt32(&blocking) == 1 {
+			fetching <- struct{}{}
+			<-release
+		}
+
+		w.Header().Set("Content-Type", applicationJson)
+		w.Write([]byte(testSession))
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu     sync.Mutex
+		events []SessionEvent
+	)
+
+	tracker := p.NewSessionTracker(SessionTrackerOptions{
+		OnEvent: func(event SessionEvent) {
+			mu.Lock()
+			events = append(events, event)
+			mu.Unlock()
+		},
+	})
+
+	if err := tracker.Reconcile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	atomic.StoreInt32(&blocking, 1)
+
+	reconciled := make(chan error)
+
+	go func() {
+		reconciled <- tracker.Reconcile(context.Background())
+	}()
+
+	<-fetching
+
+	stopped := make(chan struct{})
+
+	go func() {
+		tracker.HandleNotification(context.Background(), []PlaySessionStateNotification{
+			{SessionKey: "42", RatingKey: "10", State: SessionStateStopped},
+		})
+
+		close(stopped)
+	}()
+
+	// give the stop a chance to overtake the fetch
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if err := <-reconciled; err != nil {
+		t.Fatal(err)
+	}
+
+	<-stopped
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, event := range events[1:] {
+		if event.Type == SessionStart {
+			t.Errorf("expected the stopped session not to start again, got %v", events)
+		}
+	}
+
+	if last := events[len(events)-1]; last.Type != SessionStop {
+		t.Errorf("expected the session to end stopped, got %v", last.Type)
+	}
+
+	if len(tracker.Sessions()) != 0 {
+		t.Errorf("expected no tracked sessions, got %+v", tracker.Sessions())
+	}
+}
@@ -0,0 +1,440 @@
+package plex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultSyncStateFile is the name of the state file in the sync folder
+const defaultSyncStateFile = ".plexsync.json"
+
+// SyncAction is what Sync does to an item
+type SyncAction string
+
+// Actions of a SyncChange
+const (
+	SyncAdd    SyncAction = "add"
+	SyncUpdate SyncAction = "update"
+	SyncRemove SyncAction = "remove"
+)
+
+// SyncOptions configures Sync
+type SyncOptions struct {
+	// Download configures the downloads of new and changed items. SkipIfExists is ignored
+	// as changed files have to be replaced
+	Download DownloadOptions
+	// StatePath is the file recording what was synced. Defaults to .plexsync.json in the sync folder
+	StatePath string
+	// Prune deletes the files of items that were removed from the library
+	Prune bool
+	// DryRun only reports the changes without downloading, deleting or saving the state
+	DryRun bool
+}
+
+// SyncState is the state file of a synced folder
+type SyncState struct {
+	SectionKey string                `json:"sectionKey"`
+	SyncedAt   time.Time             `json:"syncedAt"`
+	Items      map[string]SyncedItem `json:"items"`
+}
+
+// SyncedItem is an item of a library in a synced folder
+type SyncedItem struct {
+	RatingKey string       `json:"ratingKey"`
+	Title     string       `json:"title"`
+	UpdatedAt int          `json:"updatedAt"`
+	Files     []SyncedFile `json:"files"`
+}
+
+// SyncedFile is a downloaded part of a SyncedItem
+type SyncedFile struct {
+	PartKey string `json:"partKey"`
+	// File is the path of the part on the server
+	File string `json:"file"`
+	Size int    `json:"size"`
+	// Path is relative to the sync folder
+	Path string `json:"path"`
+}
+
+// SyncChange is an item that is added, updated or removed by Sync
+type SyncChange struct {
+	Action    SyncAction
+	RatingKey string
+	Title     string
+	// Reason explains an update, i.e. the file changed on the server or is missing locally
+	Reason string
+	// Paths are the files of the item, relative to the sync folder
+	Paths []string
+}
+
+// SyncReport is the outcome of Sync
+type SyncReport struct {
+	Changes []SyncChange
+	// Unchanged is the number of items that are up to date
+	Unchanged int
+	Downloads []DownloadResult
+	// Removed are the deleted files, relative to the sync folder
+	Removed []string
+}
+
+// Sync mirrors the library section with sectionKey to dir. Items are compared by their rating key,
+// files, sizes and update time against the state file of the last sync, so only new or changed
+// items are downloaded and running it again does nothing. Items removed from the library are
+// reported and deleted when opts.Prune is set
+func (p *Plex) Sync(sectionKey, dir string, opts SyncOptions) (SyncReport, error) {
+	return p.SyncContext(context.Background(), sectionKey, dir, opts)
+}
+
+// SyncContext is like Sync but uses ctx to cancel the requests or bound their duration
+func (p *Plex) SyncContext(ctx context.Context, sectionKey, dir string, opts SyncOptions) (SyncReport, error) {
+	var report SyncReport
+
+	if opts.StatePath == "" {
+		opts.StatePath = filepath.Join(dir, defaultSyncStateFile)
+	}
+
+	state, err := LoadSyncState(opts.StatePath)
+
+	if err != nil {
+		return report, err
+	}
+
+	if state.SectionKey != "" && state.SectionKey != sectionKey {
+		return report, fmt.Errorf("%s belongs to library section %s, not %s", opts.StatePath, state.SectionKey, sectionKey)
+	}
+
+	content, err := p.GetLibraryContentContext(ctx, sectionKey, "")
+
+	if err != nil {
+		return report, err
+	}
+
+	items, err := p.expandAll(ctx, content.MediaContainer.Metadata)
+
+	if err != nil {
+		return report, err
+	}
+
+	opts.Download.SkipIfExists = false
+
+	var downloads []Metadata
+
+	seen := make(map[string]bool)
+
+	for _, item := range items {
+		seen[item.RatingKey] = true
+
+		results := downloadResults(item, dir, opts.Download)
+
+		change := SyncChange{
+			Action:    SyncAdd,
+			RatingKey: item.RatingKey,
+			Title:     item.Title,
+			Paths:     relativePaths(dir, results),
+		}
+
+		if synced, ok := state.Items[item.RatingKey]; ok {
+			change.Action = SyncUpdate
+			change.Reason = syncedItemChange(synced, item, dir)
+
+			if change.Reason == "" {
+				report.Unchanged++
+				continue
+			}
+		}
+
+		report.Changes = append(report.Changes, change)
+		downloads = append(downloads, item)
+	}
+
+	var removed []SyncedItem
+
+	for ratingKey, synced := range state.Items {
+		if seen[ratingKey] {
+			continue
+		}
+
+		removed = append(removed, synced)
+
+		change := SyncChange{Action: SyncRemove, RatingKey: ratingKey, Title: synced.Title}
+
+		for _, file := range synced.Files {
+			change.Paths = append(change.Paths, file.Path)
+		}
+
+		report.Changes = append(report.Changes, change)
+	}
+
+	// removals come from a map, sort so runs report the same changes in the same order
+	sortSyncChanges(report.Changes)
+
+	sort.Slice(removed, func(i, j int) bool {
+		return lessRatingKey(removed[i].RatingKey, removed[j].RatingKey)
+	})
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	report.Downloads, err = p.DownloadAllContext(ctx, downloads, dir, opts.Download)
+
+	state.SectionKey = sectionKey
+	state.SyncedAt = time.Now()
+
+	report.Removed = append(report.Removed, state.update(dir, report.Downloads)...)
+
+	if opts.Prune {
+		for _, synced := range removed {
+			paths, pruned := removeSyncedFiles(dir, synced.Files, nil)
+
+			report.Removed = append(report.Removed, paths...)
+
+			if pruned {
+				delete(state.Items, synced.RatingKey)
+			}
+		}
+	}
+
+	if saveErr := state.Save(opts.StatePath); saveErr != nil && err == nil {
+		err = saveErr
+	}
+
+	return report, err
+}
+
+// LoadSyncState reads the state file at path. A missing file is an empty state
+func LoadSyncState(path string) (SyncState, error) {
+	state := SyncState{Items: make(map[string]SyncedItem)}
+
+	data, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+
+	if err != nil {
+		return state, err
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("invalid sync state %s: %w", path, err)
+	}
+
+	if state.Items == nil {
+		state.Items = make(map[string]SyncedItem)
+	}
+
+	return state, nil
+}
+
+// Save writes the state to path. The file is replaced at once so an interrupted save can not corrupt it
+func (s SyncState) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// update records the items whose files were all downloaded and deletes the files they no longer
+// use, returning the deleted paths. Items with a failed file keep their previous state so they
+// are downloaded again by the next sync
+func (s *SyncState) update(dir string, results []DownloadResult) []string {
+	var order []string
+
+	byItem := make(map[string][]DownloadResult)
+
+	for _, result := range results {
+		key := result.Item.RatingKey
+
+		if _, ok := byItem[key]; !ok {
+			order = append(order, key)
+		}
+
+		byItem[key] = append(byItem[key], result)
+	}
+
+	var removed []string
+
+	for _, key := range order {
+		item := byItem[key]
+
+		synced := SyncedItem{
+			RatingKey: key,
+			Title:     item[0].Item.Title,
+			UpdatedAt: item[0].Item.UpdatedAt,
+		}
+
+		failed := false
+
+		for _, result := range item {
+			if result.Err != nil {
+				failed = true
+				break
+			}
+
+			synced.Files = append(synced.Files, SyncedFile{
+				PartKey: result.Part.Key,
+				File:    result.Part.File,
+				Size:    result.Part.Size,
+				Path:    relativePath(dir, result.Path),
+			})
+		}
+
+		if failed {
+			continue
+		}
+
+		// a renamed item leaves its previous files behind
+		if previous, ok := s.Items[key]; ok {
+			paths, _ := removeSyncedFiles(dir, previous.Files, synced.Files)
+			removed = append(removed, paths...)
+		}
+
+		s.Items[key] = synced
+	}
+
+	return removed
+}
+
+// syncedItemChange returns why item differs from its synced state, or an empty string if it does not
+func syncedItemChange(synced SyncedItem, item Metadata, dir string) string {
+	var parts []Part
+
+	for _, media := range item.Media {
+		parts = append(parts, media.Part...)
+	}
+
+	if len(parts) != len(synced.Files) {
+		return "files changed on the server"
+	}
+
+	for i, part := range parts {
+		if part.File != synced.Files[i].File || part.Size != synced.Files[i].Size {
+			return "files changed on the server"
+		}
+	}
+
+	if item.UpdatedAt != synced.UpdatedAt {
+		return "updated on the server"
+	}
+
+	for _, file := range synced.Files {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(file.Path))); err != nil {
+			return "missing locally"
+		}
+	}
+
+	return ""
+}
+
+// removeSyncedFiles deletes the files that are not kept and their empty folders. It returns the
+// deleted paths and whether every file is gone
+func removeSyncedFiles(dir string, files, keep []SyncedFile) ([]string, bool) {
+	kept := make(map[string]bool)
+
+	for _, file := range keep {
+		kept[file.Path] = true
+	}
+
+	var removed []string
+
+	ok := true
+
+	for _, file := range files {
+		if kept[file.Path] {
+			continue
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(file.Path))
+
+		// never delete outside of the sync folder, whatever the state file says
+		if rel, err := filepath.Rel(dir, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			ok = false
+			continue
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			ok = false
+			continue
+		}
+
+		removed = append(removed, file.Path)
+
+		removeEmptyFolders(dir, filepath.Dir(path))
+	}
+
+	return removed, ok
+}
+
+// removeEmptyFolders removes folder and its parents up to dir while they are empty
+func removeEmptyFolders(dir, folder string) {
+	dir = filepath.Clean(dir)
+
+	for folder = filepath.Clean(folder); folder != dir && strings.HasPrefix(folder, dir); folder = filepath.Dir(folder) {
+		if err := os.Remove(folder); err != nil {
+			return
+		}
+	}
+}
+
+// syncActionOrder is the order of the actions in a SyncReport
+var syncActionOrder = map[SyncAction]int{SyncAdd: 0, SyncUpdate: 1, SyncRemove: 2}
+
+// sortSyncChanges sorts changes by action, then rating key
+func sortSyncChanges(changes []SyncChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Action != changes[j].Action {
+			return syncActionOrder[changes[i].Action] < syncActionOrder[changes[j].Action]
+		}
+
+		return lessRatingKey(changes[i].RatingKey, changes[j].RatingKey)
+	})
+}
+
+// lessRatingKey orders numeric rating keys by value, i.e. 9 before 10
+func lessRatingKey(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+
+	return a < b
+}
+
+func relativePaths(dir string, results []DownloadResult) []string {
+	paths := make([]string, len(results))
+
+	for i, result := range results {
+		paths[i] = relativePath(dir, result.Path)
+	}
+
+	return paths
+}
+
+// relativePath is path relative to dir with forward slashes, as stored in a SyncState
+func relativePath(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,254 @@
+package plex
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSync(t *testing.T) {
+	movie := func(ratingKey, title string, updatedAt int, partKey string) string {
+		return fmt.Sprintf(`{"ratingKey":"%s","type":"movie","title":"%s","updatedAt":%d,"Media":[{"Part":[{"key":"%s","file":"/movies/%s.mkv","size":%d}]}]}`,
+			ratingKey, title, updatedAt, partKey, title, len(testFiles[partKey]))
+	}
+
+	var (
+		mu        sync.Mutex
+		library   = movie("10", "Up", 1, "/library/parts/1/file.mkv") + "," + movie("11", "Cars", 1, "/library/parts/2/file.mkv")
+		downloads int32
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if content, ok := testFiles[r.URL.Path]; ok {
+			atomic.AddInt32(&downloads, 1)
+			http.ServeContent(w, r, "file.mkv", time.Time{}, bytes.NewReader(content))
+			return
+		}
+
+		if r.URL.Path != "/library/sections/1/all" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.Header().Set("Content-Type", applicationJson)
+		fmt.Fprintf(w, `{"MediaContainer":{"Metadata":[%s]}}`, library)
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "plex-sync")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	opts := SyncOptions{Download: DownloadOptions{NameTemplate: "{title}.{ext}"}, Prune: true}
+
+	dryRun := opts
+	dryRun.DryRun = true
+
+	report, err := p.Sync("1", dir, dryRun)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Changes) != 2 || report.Changes[0].Action != SyncAdd || report.Changes[0].Paths[0] != "Up.mkv" {
+		t.Errorf("expected 2 additions, got %+v", report.Changes)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, defaultSyncStateFile)); !os.IsNotExist(err) || atomic.LoadInt32(&downloads) != 0 {
+		t.Fatal("expected a dry run to leave the folder alone")
+	}
+
+	if _, err := p.Sync("1", dir, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = p.Sync("1", dir, opts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Changes) != 0 || report.Unchanged != 2 || atomic.LoadInt32(&downloads) != 2 {
+		t.Errorf("expected a second sync to do nothing, got %+v", report)
+	}
+
+	mu.Lock()
+	library = movie("11", "Cars", 2, "/library/parts/2/file.mkv")
+	mu.Unlock()
+
+	report, err = p.Sync("1", dir, opts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Changes) != 2 || report.Changes[0].Action != SyncUpdate || report.Changes[1].Action != SyncRemove {
+		t.Errorf("expected an update and a removal, got %+v", report.Changes)
+	}
+
+	if len(report.Removed) != 1 || report.Removed[0] != "Up.mkv" {
+		t.Errorf("expected Up.mkv to be pruned, got %v", report.Removed)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "Up.mkv")); !os.IsNotExist(err) {
+		t.Error("expected Up.mkv to be deleted")
+	}
+
+	state, err := LoadSyncState(filepath.Join(dir, defaultSyncStateFile))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(state.Items) != 1 || state.Items["11"].UpdatedAt != 2 {
+		t.Errorf("unexpected state: %+v", state)
+	}
+
+	if _, err := p.Sync("2", dir, opts); err == nil {
+		t.Error("expected an error syncing another section to the folder")
+	}
+}
+
+func TestSyncCanceled(t *testing.T) {
+	movie := func(ratingKey, title string, updatedAt int, partKey string) string {
+		return fmt.Sprintf(`{"ratingKey":"%s","type":"movie","title":"%s","updatedAt":%d,"Media":[{"Part":[{"key":"%s","file":"/movies/%s.mkv","size":%d}]}]}`,
+			ratingKey, title, updatedAt, partKey, title, len(testFiles[partKey]))
+	}
+
+	var (
+		mu       sync.Mutex
+		version  = 1
+		cancel   context.CancelFunc
+		canceled int32
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		current, cancelSync := version, cancel
+		mu.Unlock()
+
+		if content, ok := testFiles[r.URL.Path]; ok {
+			// the updated files are interrupted by a cancellation
+			if cancelSync != nil {
+				atomic.AddInt32(&canceled, 1)
+				cancelSync()
+				<-r.Context().Done()
+				return
+			}
+
+			http.ServeContent(w, r, "file.mkv", time.Time{}, bytes.NewReader(content))
+			return
+		}
+
+		w.Header().Set("Content-Type", applicationJson)
+		fmt.Fprintf(w, `{"MediaContainer":{"Metadata":[%s,%s]}}`,
+			movie("10", "Up", current, "/library/parts/1/file.mkv"), movie("11", "Cars", current, "/library/parts/2/file.mkv"))
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "plex-sync")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	opts := SyncOptions{Download: DownloadOptions{NameTemplate: "{title}.{ext}", Concurrency: 1}}
+
+	if _, err := p.Sync("1", dir, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancelSync := context.WithCancel(context.Background())
+	defer cancelSync()
+
+	mu.Lock()
+	version = 2
+	cancel = cancelSync
+	mu.Unlock()
+
+	if _, err := p.SyncContext(ctx, "1", dir, opts); err != context.Canceled {
+		t.Fatalf("expected the sync to be canceled, got %v", err)
+	}
+
+	if atomic.LoadInt32(&canceled) != 1 {
+		t.Fatalf("expected a single interrupted download, got %d", canceled)
+	}
+
+	state, err := LoadSyncState(filepath.Join(dir, defaultSyncStateFile))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for ratingKey, item := range state.Items {
+		if item.UpdatedAt != 1 {
+			t.Errorf("expected %s to keep its previous state, got %+v", ratingKey, item)
+		}
+	}
+
+	mu.Lock()
+	cancel = nil
+	mu.Unlock()
+
+	report, err := p.Sync("1", dir, opts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Changes) != 2 {
+		t.Errorf("expected both updates to be retried, got %+v", report.Changes)
+	}
+}
+
+func TestSortSyncChanges(t *testing.T) {
+	changes := []SyncChange{
+		{Action: SyncRemove, RatingKey: "12"},
+		{Action: SyncAdd, RatingKey: "10"},
+		{Action: SyncRemove, RatingKey: "9"},
+		{Action: SyncUpdate, RatingKey: "3"},
+		{Action: SyncAdd, RatingKey: "2"},
+	}
+
+	sortSyncChanges(changes)
+
+	var order []string
+
+	for _, change := range changes {
+		order = append(order, string(change.Action)+" "+change.RatingKey)
+	}
+
+	if fmt.Sprint(order) != "[add 2 add 10 update 3 remove 9 remove 12]" {
+		t.Errorf("unexpected order %v", order)
+	}
+}
@@ -0,0 +1,434 @@
+package plex
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Protocols of the universal transcoder
+const (
+	// TranscodeProtocolHTTP transcodes to a single file
+	TranscodeProtocolHTTP = "http"
+	// TranscodeProtocolHLS transcodes to an HLS playlist of MPEG-TS segments
+	TranscodeProtocolHLS = "hls"
+	// TranscodeProtocolDASH transcodes to a DASH manifest. It can be played from TranscodeURL but not downloaded
+	TranscodeProtocolDASH = "dash"
+)
+
+const (
+	defaultHLSPollInterval = time.Second
+	// maxHLSIdlePolls is how many polls of a playlist without new segments end a download
+	maxHLSIdlePolls = 30
+	// stopTranscodeTimeout bounds the stop request sent after a download
+	stopTranscodeTimeout = 5 * time.Second
+)
+
+// TranscodeOptions configures a transcode of the universal transcoder
+type TranscodeOptions struct {
+	// Protocol is TranscodeProtocolHTTP, TranscodeProtocolHLS or TranscodeProtocolDASH. Defaults to http
+	Protocol string
+	// MediaIndex and PartIndex select the media and part of the item, starting at 0
+	MediaIndex int
+	PartIndex  int
+	// VideoResolution is the maximum resolution, i.e. 1280x720
+	VideoResolution string
+	// MaxVideoBitrate is in kbps, i.e. 2000
+	MaxVideoBitrate int
+	// VideoCodec defaults to h264
+	VideoCodec string
+	// AudioCodec defaults to aac
+	AudioCodec string
+	// Container defaults to mp4 for http and mpegts for hls and dash
+	Container string
+	// BurnSubtitles burns the selected subtitle stream of the part into the video
+	BurnSubtitles bool
+	// SubtitleSize is the size of burned subtitles in percent, i.e. 100
+	SubtitleSize int
+	// Offset starts the transcode into the item
+	Offset time.Duration
+	// Session identifies the transcode on the server. A random one is used if empty
+	Session string
+	// OnProgress is called with the bytes written while downloading
+	OnProgress func(written int64)
+}
+
+// TranscodeURL returns the url of a transcode of the item with ratingKey, including the token,
+// for players or tools that can not send the Plex headers
+func (p *Plex) TranscodeURL(ratingKey string, opts TranscodeOptions) (string, error) {
+	query, err := p.transcodeQuery(ratingKey, &opts)
+
+	if err != nil {
+		return "", err
+	}
+
+	query.Set("X-Plex-Token", p.Token)
+	query.Set("X-Plex-Client-Identifier", p.ClientIdentifier)
+	query.Set("X-Plex-Product", p.Headers.Product)
+	query.Set("X-Plex-Platform", p.Headers.Platform)
+
+	return p.URL + transcodePath(opts.Protocol) + "?" + query.Encode(), nil
+}
+
+// DownloadTranscoded transcodes the item with ratingKey and saves it to path. HLS segments are
+// joined into a single MPEG-TS file. The file is written with a .part suffix and renamed once
+// complete. It returns the number of bytes written
+func (p *Plex) DownloadTranscoded(ratingKey, path string, opts TranscodeOptions) (int64, error) {
+	return p.DownloadTranscodedContext(context.Background(), ratingKey, path, opts)
+}
+
+// DownloadTranscodedContext is like DownloadTranscoded but uses ctx to cancel the requests or bound their duration
+func (p *Plex) DownloadTranscodedContext(ctx context.Context, ratingKey, path string, opts TranscodeOptions) (int64, error) {
+	query, err := p.transcodeQuery(ratingKey, &opts)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if opts.Protocol == TranscodeProtocolDASH {
+		return 0, fmt.Errorf("dash transcodes can not be downloaded, use %s or %s", TranscodeProtocolHTTP, TranscodeProtocolHLS)
+	}
+
+	// free the transcoder once we are done, even when the download failed or ctx is done. The server
+	// ends idle transcodes itself, so a failed stop is not worth failing the download for
+	defer func() {
+		stopCtx, cancel := context.WithTimeout(context.Background(), stopTranscodeTimeout)
+		defer cancel()
+
+		p.StopTranscodeContext(stopCtx, opts.Session)
+	}()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return 0, err
+	}
+
+	tmp := path + partialDownloadSuffix
+
+	file, err := os.Create(tmp)
+
+	if err != nil {
+		return 0, err
+	}
+
+	w := &transcodeWriter{w: file, onProgress: opts.OnProgress}
+
+	start := p.URL + transcodePath(opts.Protocol) + "?" + query.Encode()
+
+	if opts.Protocol == TranscodeProtocolHLS {
+		err = p.downloadHLS(ctx, start, w, defaultHLSPollInterval)
+	} else {
+		err = p.fetchTranscode(ctx, start, w)
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("failed to transcode %s: %w", ratingKey, err)
+	}
+
+	if w.written == 0 {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("failed to transcode %s: the server sent no data", ratingKey)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, err
+	}
+
+	return w.written, nil
+}
+
+// StopTranscode stops the transcode with session on the server
+func (p *Plex) StopTranscode(session string) error {
+	return p.StopTranscodeContext(context.Background(), session)
+}
+
+// StopTranscodeContext is like StopTranscode but uses ctx to cancel the request or bound its duration
+func (p *Plex) StopTranscodeContext(ctx context.Context, session string) error {
+	query := fmt.Sprintf("%s/video/:/transcode/universal/stop?session=%s", p.URL, url.QueryEscape(session))
+
+	return p.requestJSON(ctx, http.MethodGet, query, nil)
+}
+
+// transcodeQuery builds the parameters of a transcode and fills in the defaults of opts
+func (p *Plex) transcodeQuery(ratingKey string, opts *TranscodeOptions) (url.Values, error) {
+	if ratingKey == "" {
+		return nil, fmt.Errorf(ErrorCommon, ErrorKeyIsRequired)
+	}
+
+	if opts.Protocol == "" {
+		opts.Protocol = TranscodeProtocolHTTP
+	}
+
+	if opts.Container == "" {
+		opts.Container = "mpegts"
+
+		if opts.Protocol == TranscodeProtocolHTTP {
+			opts.Container = "mp4"
+		}
+	}
+
+	if opts.VideoCodec == "" {
+		opts.VideoCodec = "h264"
+	}
+
+	if opts.AudioCodec == "" {
+		opts.AudioCodec = "aac"
+	}
+
+	if opts.Session == "" {
+		id, err := uuid.NewRandom()
+
+		if err != nil {
+			return nil, err
+		}
+
+		opts.Session = id.String()
+	}
+
+	var transcodeContext string
+
+	switch opts.Protocol {
+	case TranscodeProtocolHTTP:
+		transcodeContext = "static"
+	case TranscodeProtocolHLS, TranscodeProtocolDASH:
+		transcodeContext = "streaming"
+	default:
+		return nil, fmt.Errorf("unknown transcode protocol %q", opts.Protocol)
+	}
+
+	query := url.Values{}
+
+	query.Set("path", "/library/metadata/"+ratingKey)
+	query.Set("mediaIndex", strconv.Itoa(opts.MediaIndex))
+	query.Set("partIndex", strconv.Itoa(opts.PartIndex))
+	query.Set("protocol", opts.Protocol)
+	query.Set("session", opts.Session)
+	query.Set("X-Plex-Session-Identifier", opts.Session)
+	query.Set("directPlay", "0")
+	query.Set("directStream", "1")
+	query.Set("fastSeek", "1")
+	query.Set("copyts", "1")
+
+	// the server picks the codecs from the profile of the client, so add one for ours
+	query.Set("X-Plex-Client-Profile-Extra", fmt.Sprintf(
+		"add-transcode-target(type=videoProfile&context=%s&protocol=%s&container=%s&videoCodec=%s&audioCodec=%s)",
+		transcodeContext, opts.Protocol, opts.Container, opts.VideoCodec, opts.AudioCodec,
+	))
+
+	if opts.VideoResolution != "" {
+		query.Set("videoResolution", opts.VideoResolution)
+	}
+
+	if opts.MaxVideoBitrate > 0 {
+		query.Set("maxVideoBitrate", strconv.Itoa(opts.MaxVideoBitrate))
+		query.Set("videoQuality", "100")
+	}
+
+	if opts.BurnSubtitles {
+		query.Set("subtitles", "burn")
+	}
+
+	if opts.SubtitleSize > 0 {
+		query.Set("subtitleSize", strconv.Itoa(opts.SubtitleSize))
+	}
+
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.Itoa(int(opts.Offset.Seconds())))
+	}
+
+	return query, nil
+}
+
+func transcodePath(protocol string) string {
+	switch protocol {
+	case TranscodeProtocolHLS:
+		return "/video/:/transcode/universal/start.m3u8"
+	case TranscodeProtocolDASH:
+		return "/video/:/transcode/universal/start.mpd"
+	}
+
+	return "/video/:/transcode/universal/start"
+}
+
+// fetchTranscode copies the response of query to w
+func (p *Plex) fetchTranscode(ctx context.Context, query string, w io.Writer) error {
+	resp, err := p.grab(ctx, query, p.Headers)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+
+	return err
+}
+
+// downloadHLS writes the segments of the playlist at query to w. A playlist that is still growing
+// is polled every interval until it ends, or fails after maxHLSIdlePolls polls without new segments
+func (p *Plex) downloadHLS(ctx context.Context, query string, w io.Writer, interval time.Duration) error {
+	playlist, err := p.getHLSPlaylist(ctx, query)
+
+	if err != nil {
+		return err
+	}
+
+	// a master playlist points at the playlist of the segments
+	if len(playlist.variants) > 0 {
+		query = playlist.variants[0]
+
+		if playlist, err = p.getHLSPlaylist(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	fetched, idle := 0, 0
+
+	for {
+		if len(playlist.segments) > fetched {
+			idle = 0
+		} else if !playlist.ended {
+			idle++
+
+			if idle > maxHLSIdlePolls {
+				return fmt.Errorf("hls playlist %s stopped growing without ending", query)
+			}
+		}
+
+		for _, segment := range playlist.segments[fetched:] {
+			if err := p.fetchTranscode(ctx, segment, w); err != nil {
+				return fmt.Errorf("failed to fetch segment %s: %w", segment, err)
+			}
+
+			fetched++
+		}
+
+		if playlist.ended {
+			return nil
+		}
+
+		timer := time.NewTimer(interval)
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+
+		if playlist, err = p.getHLSPlaylist(ctx, query); err != nil {
+			return err
+		}
+
+		if len(playlist.segments) < fetched {
+			return fmt.Errorf("hls playlist %s lost segments", query)
+		}
+	}
+}
+
+// hlsPlaylist is an HLS playlist with absolute urls
+type hlsPlaylist struct {
+	variants []string
+	segments []string
+	// ended is true when the playlist will not get more segments
+	ended bool
+}
+
+func (p *Plex) getHLSPlaylist(ctx context.Context, query string) (hlsPlaylist, error) {
+	var playlist hlsPlaylist
+
+	base, err := url.Parse(query)
+
+	if err != nil {
+		return playlist, err
+	}
+
+	resp, err := p.get(ctx, query, p.Headers)
+
+	if err != nil {
+		return playlist, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return playlist, newAPIError(resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "#EXTM3U" {
+		return playlist, fmt.Errorf("%s is not an hls playlist", query)
+	}
+
+	variant := false
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF"):
+			variant = true
+		case line == "#EXT-X-ENDLIST":
+			playlist.ended = true
+		case strings.HasPrefix(line, "#"):
+		default:
+			ref, err := url.Parse(line)
+
+			if err != nil {
+				return playlist, fmt.Errorf("invalid uri %q in hls playlist: %w", line, err)
+			}
+
+			uri := base.ResolveReference(ref).String()
+
+			if variant {
+				playlist.variants = append(playlist.variants, uri)
+			} else {
+				playlist.segments = append(playlist.segments, uri)
+			}
+
+			variant = false
+		}
+	}
+
+	return playlist, scanner.Err()
+}
+
+// transcodeWriter counts the bytes written and reports them
+type transcodeWriter struct {
+	w          io.Writer
+	written    int64
+	onProgress func(written int64)
+}
+
+func (t *transcodeWriter) Write(b []byte) (int, error) {
+	n, err := t.w.Write(b)
+
+	t.written += int64(n)
+
+	if t.onProgress != nil {
+		t.onProgress(t.written)
+	}
+
+	return n, err
+}
@@ -0,0 +1,188 @@
+package plex
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTranscodeURL(t *testing.T) {
+	p, err := New("http://192.168.1.2:32400", "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transcodeURL, err := p.TranscodeURL("10", TranscodeOptions{
+		Protocol:        TranscodeProtocolHLS,
+		VideoResolution: "1280x720",
+		MaxVideoBitrate: 2000,
+		BurnSubtitles:   true,
+		Session:         "session1",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(transcodeURL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := u.Query()
+
+	if u.Path != "/video/:/transcode/universal/start.m3u8" {
+		t.Errorf("unexpected path %s", u.Path)
+	}
+
+	expected := map[string]string{
+		"path":            "/library/metadata/10",
+		"protocol":        "hls",
+		"session":         "session1",
+		"videoResolution": "1280x720",
+		"maxVideoBitrate": "2000",
+		"subtitles":       "burn",
+		"X-Plex-Token":    "abc123",
+	}
+
+	for key, value := range expected {
+		if query.Get(key) != value {
+			t.Errorf("expected %s to be %s, got %s", key, value, query.Get(key))
+		}
+	}
+
+	if profile := query.Get("X-Plex-Client-Profile-Extra"); !strings.Contains(profile, "container=mpegts&videoCodec=h264&audioCodec=aac") {
+		t.Errorf("unexpected client profile %s", profile)
+	}
+
+	if _, err := p.TranscodeURL("10", TranscodeOptions{Protocol: "rtsp"}); err == nil {
+		t.Error("expected an error for an unknown protocol")
+	}
+}
+
+func TestDownloadTranscoded(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		stopped []string
+		polls   int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/video/:/transcode/universal/start":
+			w.Write([]byte("mp4 data"))
+		case "/video/:/transcode/universal/start.m3u8":
+			w.Write([]byte("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=2000000,RESOLUTION=1280x720\nsession/" + r.URL.Query().Get("session") + "/base/index.m3u8\n"))
+		case "/video/:/transcode/universal/session/s1/base/index.m3u8":
+			w.Write([]byte("#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10,\n00000.ts\n#EXTINF:10,\n00001.ts\n#EXT-X-ENDLIST\n"))
+		case "/live/index.m3u8":
+			polls++
+
+			// the playlist grows until it ends
+			if polls == 1 {
+				w.Write([]byte("#EXTM3U\n#EXTINF:10,\n/video/:/transcode/universal/session/s1/base/00000.ts\n"))
+				return
+			}
+
+			w.Write([]byte("#EXTM3U\n#EXTINF:10,\n/video/:/transcode/universal/session/s1/base/00000.ts\n#EXTINF:10,\n/video/:/transcode/universal/session/s1/base/00001.ts\n#EXT-X-ENDLIST\n"))
+		case "/stalled/index.m3u8":
+			w.Write([]byte("#EXTM3U\n#EXTINF:10,\n/video/:/transcode/universal/session/s1/base/00000.ts\n"))
+		case "/video/:/transcode/universal/session/s1/base/00000.ts":
+			w.Write([]byte("segment0;"))
+		case "/video/:/transcode/universal/session/s1/base/00001.ts":
+			w.Write([]byte("segment1;"))
+		case "/video/:/transcode/universal/stop":
+			stopped = append(stopped, r.URL.Query().Get("session"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "plex-transcode")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		protocol string
+		expected string
+	}{
+		{TranscodeProtocolHTTP, "mp4 data"},
+		{TranscodeProtocolHLS, "segment0;segment1;"},
+	}
+
+	for _, tc := range testCases {
+		path := filepath.Join(dir, tc.protocol, "movie")
+
+		written, err := p.DownloadTranscoded("10", path, TranscodeOptions{Protocol: tc.protocol, Session: "s1"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := ioutil.ReadFile(path)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(data) != tc.expected || written != int64(len(data)) {
+			t.Errorf("%s: expected %q, got %q (%d bytes)", tc.protocol, tc.expected, data, written)
+		}
+	}
+
+	mu.Lock()
+
+	if len(stopped) != 2 || stopped[0] != "s1" {
+		t.Errorf("expected the transcodes to be stopped, got %v", stopped)
+	}
+
+	mu.Unlock()
+
+	var live bytes.Buffer
+
+	if err := p.downloadHLS(context.Background(), server.URL+"/live/index.m3u8", &live, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if live.String() != "segment0;segment1;" {
+		t.Errorf("expected each segment of a growing playlist once, got %q", live.String())
+	}
+
+	var stalled bytes.Buffer
+
+	if err := p.downloadHLS(context.Background(), server.URL+"/stalled/index.m3u8", &stalled, 0); err == nil || !strings.Contains(err.Error(), "stopped growing") {
+		t.Errorf("expected a playlist that stopped growing to fail, got %v", err)
+	}
+
+	if stalled.String() != "segment0;" {
+		t.Errorf("expected the segment of the stalled playlist once, got %q", stalled.String())
+	}
+
+	if _, err := p.DownloadTranscoded("10", filepath.Join(dir, "dash"), TranscodeOptions{Protocol: TranscodeProtocolDASH}); err == nil {
+		t.Error("expected an error downloading a dash transcode")
+	}
+}
@@ -2,8 +2,13 @@ package plex
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
-	"time"
+	"strings"
 )
 
 // func (p *Plex) options(query string) (*http.Response, error) {
@@ -24,252 +29,199 @@ import (
 // 	return resp, nil
 // }
 
-func (p *Plex) grab(query string, h headers) (*http.Response, error) {
-	client := p.DownloadClient
-
-	req, reqErr := http.NewRequest("GET", query, nil)
+func (p *Plex) grab(ctx context.Context, query string, h headers) (*http.Response, error) {
+	return p.send(ctx, &p.DownloadClient, http.MethodGet, query, nil, h)
+}
 
-	if reqErr != nil {
-		return &http.Response{}, reqErr
-	}
+func (p *Plex) get(ctx context.Context, query string, h headers) (*http.Response, error) {
+	return p.send(ctx, &p.HTTPClient, http.MethodGet, query, nil, h)
+}
 
-	req.Header.Add("Accept", h.Accept)
-	req.Header.Add("X-Plex-Platform", h.Platform)
-	req.Header.Add("X-Plex-Platform-Version", h.PlatformVersion)
-	req.Header.Add("X-Plex-Provides", h.Provides)
-	req.Header.Add("X-Plex-Client-Identifier", p.ClientIdentifier)
-	req.Header.Add("X-Plex-Product", h.Product)
-	req.Header.Add("X-Plex-Version", h.Version)
-	req.Header.Add("X-Plex-Device", h.Device)
-	// req.Header.Add("X-Plex-Container-Size", h.ContainerSize)
-	// req.Header.Add("X-Plex-Container-Start", h.ContainerStart)
-	req.Header.Add("X-Plex-Token", p.Token)
+func (p *Plex) delete(ctx context.Context, query string, h headers) (*http.Response, error) {
+	return p.send(ctx, &p.HTTPClient, http.MethodDelete, query, nil, h)
+}
 
-	// optional headers
-	if h.TargetClientIdentifier != "" {
-		req.Header.Add("X-Plex-Target-Identifier", h.TargetClientIdentifier)
-	}
+func (p *Plex) post(ctx context.Context, query string, body []byte, h headers) (*http.Response, error) {
+	return p.send(ctx, &p.HTTPClient, http.MethodPost, query, body, h)
+}
 
-	resp, err := client.Do(req)
+// requestJSON sends a request without a body and decodes a successful json
+// response into result. The response body is discarded when result is nil
+func (p *Plex) requestJSON(ctx context.Context, method, query string, result interface{}) error {
+	resp, err := p.send(ctx, &p.HTTPClient, method, query, nil, p.Headers)
 
 	if err != nil {
-		return &http.Response{}, err
+		return err
 	}
 
-	return resp, nil
-}
-
-func (p *Plex) get(query string, h headers) (*http.Response, error) {
-	client := p.HTTPClient
+	defer resp.Body.Close()
 
-	req, reqErr := http.NewRequest("GET", query, nil)
-
-	if reqErr != nil {
-		return &http.Response{}, reqErr
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp)
 	}
 
-	req.Header.Add("Accept", h.Accept)
-	req.Header.Add("X-Plex-Platform", h.Platform)
-	req.Header.Add("X-Plex-Platform-Version", h.PlatformVersion)
-	req.Header.Add("X-Plex-Provides", h.Provides)
-	req.Header.Add("X-Plex-Client-Identifier", p.ClientIdentifier)
-	req.Header.Add("X-Plex-Product", h.Product)
-	req.Header.Add("X-Plex-Version", h.Version)
-	req.Header.Add("X-Plex-Device", h.Device)
-	// req.Header.Add("X-Plex-Container-Size", h.ContainerSize)
-	// req.Header.Add("X-Plex-Container-Start", h.ContainerStart)
-	req.Header.Add("X-Plex-Token", p.Token)
-
-	// optional headers
-	if h.TargetClientIdentifier != "" {
-		req.Header.Add("X-Plex-Target-Identifier", h.TargetClientIdentifier)
+	if result == nil {
+		return nil
 	}
 
-	resp, err := client.Do(req)
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// libraryURI returns the uri plex uses to reference library items of this server
+// when creating playlists and play queues, i.e. server://{machineID}/com.plexapp.plugins.library/library/metadata/1,2
+func (p *Plex) libraryURI(ctx context.Context, path string) (string, error) {
+	machineID, err := p.serverIdentifier(ctx)
 
 	if err != nil {
-		return &http.Response{}, err
+		return "", err
 	}
 
-	return resp, nil
+	return fmt.Sprintf("server://%s/com.plexapp.plugins.library%s", machineID, path), nil
 }
 
-func get(query string, h headers) (*http.Response, error) {
-	client := http.Client{
-		Timeout: 3 * time.Second,
-	}
-
-	req, err := http.NewRequest("GET", query, nil)
-
-	if err != nil {
-		return &http.Response{}, err
+// serverIdentifier returns the machine identifier of the server at p.URL
+func (p *Plex) serverIdentifier(ctx context.Context) (string, error) {
+	var identity struct {
+		MediaContainer struct {
+			MachineIdentifier string `json:"machineIdentifier"`
+		} `json:"MediaContainer"`
 	}
 
-	req.Header.Add("Accept", h.Accept)
-	req.Header.Add("X-Plex-Platform", h.Platform)
-	req.Header.Add("X-Plex-Platform-Version", h.PlatformVersion)
-	req.Header.Add("X-Plex-Provides", h.Provides)
-	req.Header.Add("X-Plex-Client-Identifier", h.ClientIdentifier)
-	req.Header.Add("X-Plex-Product", h.Product)
-	req.Header.Add("X-Plex-Version", h.Version)
-	req.Header.Add("X-Plex-Device", h.Device)
-	// req.Header.Add("X-Plex-Container-Size", h.ContainerSize)
-	// req.Header.Add("X-Plex-Container-Start", h.ContainerStart)
-	if h.Token != "" {
-		req.Header.Add("X-Plex-Token", h.Token)
+	if err := p.requestJSON(ctx, http.MethodGet, p.URL+"/identity", &identity); err != nil {
+		return "", err
 	}
 
-	resp, err := client.Do(req)
-
-	if err != nil {
-		return &http.Response{}, err
+	if identity.MediaContainer.MachineIdentifier == "" {
+		return "", errors.New("could not fetch machine id")
 	}
 
-	return resp, nil
+	return identity.MediaContainer.MachineIdentifier, nil
 }
 
-func (p *Plex) delete(query string, h headers) (*http.Response, error) {
-	client := p.HTTPClient
-
-	req, reqErr := http.NewRequest("DELETE", query, nil)
-
-	if reqErr != nil {
-		return &http.Response{}, reqErr
+// newAnonymousClient creates a client without a token for plex.tv requests
+// made before signing in, such as requesting a pin
+func newAnonymousClient(opts ...Option) *Plex {
+	p := &Plex{
+		HTTPClient: http.Client{
+			Timeout: defaultTimeout,
+		},
 	}
 
-	req.Header.Add("Accept", h.Accept)
-	req.Header.Add("X-Plex-Platform", h.Platform)
-	req.Header.Add("X-Plex-Platform-Version", h.PlatformVersion)
-	req.Header.Add("X-Plex-Provides", h.Provides)
-	req.Header.Add("X-Plex-Client-Identifier", p.ClientIdentifier)
-	req.Header.Add("X-Plex-Product", h.Product)
-	req.Header.Add("X-Plex-Version", h.Version)
-	req.Header.Add("X-Plex-Device", h.Device)
-	// req.Header.Add("X-Plex-Container-Size", h.ContainerSize)
-	// req.Header.Add("X-Plex-Container-Start", h.ContainerStart)
-	req.Header.Add("X-Plex-Token", p.Token)
-
-	// optional headers
-	if h.TargetClientIdentifier != "" {
-		req.Header.Add("X-Plex-Target-Identifier", h.TargetClientIdentifier)
+	for _, opt := range opts {
+		opt(p)
 	}
 
-	resp, err := client.Do(req)
+	return p
+}
 
-	if err != nil {
-		return &http.Response{}, err
+// plexTVURL returns the plex.tv endpoint of this client
+func (p Plex) plexTVURL() string {
+	if p.PlexTVURL != "" {
+		return strings.TrimSuffix(p.PlexTVURL, "/")
 	}
 
-	return resp, nil
+	return plexURL
 }
 
-func (p *Plex) post(query string, body []byte, h headers) (*http.Response, error) {
-	client := p.HTTPClient
+func (p *Plex) put(ctx context.Context, query string, body []byte, h headers) (*http.Response, error) {
+	return p.send(ctx, &p.HTTPClient, http.MethodPut, query, body, h)
+}
 
-	req, err := http.NewRequest("POST", query, bytes.NewBuffer(body))
+// send is the single request pipeline: it assembles the plex headers and passes
+// the request through the middleware chain and retry policy before handing it to client
+func (p *Plex) send(ctx context.Context, client *http.Client, method, query string, body []byte, h headers) (*http.Response, error) {
+	req, err := p.newRequest(ctx, method, query, body, h)
 
 	if err != nil {
 		return &http.Response{}, err
 	}
 
-	// req.Header.Set("Content-Type", applicationJson)
-	req.Header.Add("Accept", h.Accept)
-	req.Header.Add("X-Plex-Platform", h.Platform)
-	req.Header.Add("X-Plex-Platform-Version", h.PlatformVersion)
-	req.Header.Add("X-Plex-Provides", h.Provides)
-	req.Header.Add("X-Plex-Client-Identifier", p.ClientIdentifier)
-	req.Header.Add("X-Plex-Product", h.Product)
-	req.Header.Add("X-Plex-Version", h.Version)
-	req.Header.Add("X-Plex-Device", h.Device)
-	// req.Header.Add("X-Plex-Container-Size", h.ContainerSize)
-	// req.Header.Add("X-Plex-Container-Start", h.ContainerStart)
-	req.Header.Add("X-Plex-Token", p.Token)
-	req.Header.Add("Content-Type", h.ContentType)
+	handler := RequestHandler(client.Do)
 
-	// optional headers
-	if h.TargetClientIdentifier != "" {
-		req.Header.Add("X-Plex-Target-Identifier", h.TargetClientIdentifier)
+	if p.Retry != nil && !h.NoRetry {
+		handler = p.Retry.retry(handler)
 	}
 
-	resp, err := client.Do(req)
+	resp, err := p.chain(handler)(req)
 
-	if err != nil {
+	// a canceled or expired context is reported as is
+	if err != nil && ctx.Err() != nil {
 		return &http.Response{}, err
 	}
 
-	return resp, nil
-}
-
-// post sends a POST request and is the same as plex.post while omitting the plex token header
-func post(query string, body []byte, h headers) (*http.Response, error) {
-	client := http.Client{
-		Timeout: 3 * time.Second,
+	if err != nil {
+		return &http.Response{}, &RequestError{
+			Method:   method,
+			Endpoint: endpointOf(req),
+			Err:      err,
+		}
 	}
 
-	req, err := http.NewRequest("POST", query, bytes.NewBuffer(body))
+	return resp, nil
+}
 
-	if err != nil {
-		return &http.Response{}, err
-	}
+func (p *Plex) newRequest(ctx context.Context, method, query string, body []byte, h headers) (*http.Request, error) {
+	var reqBody io.Reader
 
-	req.Header.Add("Accept", h.Accept)
-	req.Header.Add("X-Plex-Platform", h.Platform)
-	req.Header.Add("X-Plex-Platform-Version", h.PlatformVersion)
-	req.Header.Add("X-Plex-Provides", h.Provides)
-	req.Header.Add("X-Plex-Client-Identifier", h.ClientIdentifier)
-	req.Header.Add("X-Plex-Product", h.Product)
-	req.Header.Add("X-Plex-Version", h.Version)
-	req.Header.Add("X-Plex-Device", h.Device)
-	// req.Header.Add("X-Plex-Container-Size", h.ContainerSize)
-	// req.Header.Add("X-Plex-Container-Start", h.ContainerStart)
-	if h.Token != "" {
-		req.Header.Add("X-Plex-Token", h.Token)
+	if method == http.MethodPost || method == http.MethodPut {
+		reqBody = bytes.NewBuffer(body)
 	}
-	req.Header.Add("Content-Type", h.ContentType)
 
-	resp, err := client.Do(req)
+	req, err := http.NewRequestWithContext(ctx, method, query, reqBody)
 
 	if err != nil {
-		return &http.Response{}, err
+		return nil, err
 	}
 
-	return resp, nil
-}
+	// the client's identifier and token take precedence over the ones in the headers
+	clientIdentifier := p.ClientIdentifier
 
-func (p *Plex) put(query string, body []byte, h headers) (*http.Response, error) {
-	client := p.HTTPClient
+	if clientIdentifier == "" {
+		clientIdentifier = h.ClientIdentifier
+	}
 
-	req, reqErr := http.NewRequest("PUT", query, bytes.NewBuffer(body))
+	token := p.Token
 
-	if reqErr != nil {
-		return &http.Response{}, reqErr
+	if token == "" {
+		token = h.Token
 	}
 
-	req.Header.Set("Content-Type", h.ContentType)
 	req.Header.Add("Accept", h.Accept)
 	req.Header.Add("X-Plex-Platform", h.Platform)
 	req.Header.Add("X-Plex-Platform-Version", h.PlatformVersion)
 	req.Header.Add("X-Plex-Provides", h.Provides)
-	req.Header.Add("X-Plex-Client-Identifier", p.ClientIdentifier)
+	req.Header.Add("X-Plex-Client-Identifier", clientIdentifier)
 	req.Header.Add("X-Plex-Product", h.Product)
 	req.Header.Add("X-Plex-Version", h.Version)
 	req.Header.Add("X-Plex-Device", h.Device)
-	// req.Header.Add("X-Plex-Container-Size", h.ContainerSize)
-	// req.Header.Add("X-Plex-Container-Start", h.ContainerStart)
-	req.Header.Add("X-Plex-Token", p.Token)
+	if token != "" {
+		req.Header.Add("X-Plex-Token", token)
+	}
+
+	if reqBody != nil {
+		req.Header.Add("Content-Type", h.ContentType)
+	}
 
 	// optional headers
 	if h.TargetClientIdentifier != "" {
 		req.Header.Add("X-Plex-Target-Identifier", h.TargetClientIdentifier)
 	}
 
-	resp, err := client.Do(req)
+	// pagination
+	if h.ContainerStart != "" {
+		req.Header.Add("X-Plex-Container-Start", h.ContainerStart)
+	}
 
-	if err != nil {
-		return &http.Response{}, err
+	if h.ContainerSize != "" {
+		req.Header.Add("X-Plex-Container-Size", h.ContainerSize)
 	}
 
-	return resp, nil
+	// partial downloads
+	if h.Range != "" {
+		req.Header.Add("Range", h.Range)
+	}
+
+	return req, nil
 }
 
 func boolToOneOrZero(input bool) string {
@@ -1,108 +1,417 @@
 package plex
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"path"
+	"strings"
 )
 
+const (
+	// maxWebhookMemory is how much of a webhook request is held in memory, the rest is stored in temporary files
+	maxWebhookMemory = 10 << 20
+	// maxWebhookBody is the largest webhook request read, besides a thumbnail larger than the default
+	maxWebhookBody = 16 << 20
+	// maxWebhookPayload leaves room for the payload next to the largest thumbnail
+	maxWebhookPayload = 1 << 20
+)
+
+// Webhook events sent by Plex Media Server
+const (
+	WebhookMediaPlay              = "media.play"
+	WebhookMediaPause             = "media.pause"
+	WebhookMediaResume            = "media.resume"
+	WebhookMediaStop              = "media.stop"
+	WebhookMediaScrobble          = "media.scrobble"
+	WebhookMediaRate              = "media.rate"
+	WebhookLibraryNew             = "library.new"
+	WebhookLibraryOnDeck          = "library.on.deck"
+	WebhookAdminDatabaseBackup    = "admin.database.backup"
+	WebhookAdminDatabaseCorrupted = "admin.database.corrupted"
+	WebhookDeviceNew              = "device.new"
+	WebhookPlaybackStarted        = "playback.started"
+
+	// WebhookAnyEvent registers a handler that receives every event, including unknown ones
+	WebhookAnyEvent = "*"
+)
+
+var webhookEventNames = map[string]bool{
+	WebhookMediaPlay:              true,
+	WebhookMediaPause:             true,
+	WebhookMediaResume:            true,
+	WebhookMediaStop:              true,
+	WebhookMediaScrobble:          true,
+	WebhookMediaRate:              true,
+	WebhookLibraryNew:             true,
+	WebhookLibraryOnDeck:          true,
+	WebhookAdminDatabaseBackup:    true,
+	WebhookAdminDatabaseCorrupted: true,
+	WebhookDeviceNew:              true,
+	WebhookPlaybackStarted:        true,
+	WebhookAnyEvent:               true,
+}
+
 // Webhook contains a webhooks information
 type Webhook struct {
-	Event   string `json:"event"`
-	User    bool   `json:"user"`
-	Owner   bool   `json:"owner"`
-	Account struct {
-		ID    int    `json:"id"`
-		Thumb string `json:"thumb"`
-		Title string `json:"title"`
-	} `json:"Account"`
-	Server struct {
-		Title string `json:"title"`
-		UUID  string `json:"uuid"`
-	} `json:"Server"`
-	Player struct {
-		Local         bool   `json:"local"`
-		PublicAddress string `json:"PublicAddress"`
-		Title         string `json:"title"`
-		UUID          string `json:"uuid"`
-	} `json:"Player"`
-	Metadata struct {
-		LibrarySectionType   string `json:"librarySectionType"`
-		RatingKey            string `json:"ratingKey"`
-		Key                  string `json:"key"`
-		ParentRatingKey      string `json:"parentRatingKey"`
-		GrandparentRatingKey string `json:"grandparentRatingKey"`
-		GUID                 string `json:"guid"`
-		LibrarySectionID     int    `json:"librarySectionID"`
-		MediaType            string `json:"type"`
-		Title                string `json:"title"`
-		GrandparentKey       string `json:"grandparentKey"`
-		ParentKey            string `json:"parentKey"`
-		GrandparentTitle     string `json:"grandparentTitle"`
-		ParentTitle          string `json:"parentTitle"`
-		Summary              string `json:"summary"`
-		Index                int    `json:"index"`
-		ParentIndex          int    `json:"parentIndex"`
-		RatingCount          int    `json:"ratingCount"`
-		Thumb                string `json:"thumb"`
-		Art                  string `json:"art"`
-		ParentThumb          string `json:"parentThumb"`
-		GrandparentThumb     string `json:"grandparentThumb"`
-		GrandparentArt       string `json:"grandparentArt"`
-		AddedAt              int    `json:"addedAt"`
-		UpdatedAt            int    `json:"updatedAt"`
-	} `json:"Metadata"`
+	Event   string         `json:"event"`
+	User    bool           `json:"user"`
+	Owner   bool           `json:"owner"`
+	Account WebhookAccount `json:"Account"`
+	Server  WebhookServer  `json:"Server"`
+	Player  WebhookPlayer  `json:"Player"`
+	// Device is only set for device.new
+	Device WebhookDevice `json:"Device"`
+	// Rating is the rating given by a media.rate event
+	Rating   float64         `json:"rating"`
+	Metadata WebhookMetadata `json:"Metadata"`
+	// Thumbnail is the poster attached to the webhook, if any
+	Thumbnail *WebhookThumbnail `json:"-"`
+}
+
+// WebhookAccount is the account that triggered a webhook
+type WebhookAccount struct {
+	ID    int    `json:"id"`
+	Thumb string `json:"thumb"`
+	Title string `json:"title"`
+}
+
+// WebhookServer is the server that sent a webhook
+type WebhookServer struct {
+	Title string `json:"title"`
+	UUID  string `json:"uuid"`
+}
+
+// WebhookPlayer is the client playing the media of a webhook
+type WebhookPlayer struct {
+	Local         bool   `json:"local"`
+	PublicAddress string `json:"PublicAddress"`
+	Title         string `json:"title"`
+	UUID          string `json:"uuid"`
+}
+
+// WebhookDevice is a device that connected to the server for the first time
+type WebhookDevice struct {
+	ID               int    `json:"id"`
+	Name             string `json:"name"`
+	Product          string `json:"product"`
+	Platform         string `json:"platform"`
+	ClientIdentifier string `json:"clientIdentifier"`
+	CreatedAt        int    `json:"createdAt"`
+}
+
+// WebhookMetadata is the media a webhook is about
+type WebhookMetadata struct {
+	LibrarySectionType    string       `json:"librarySectionType"`
+	LibrarySectionTitle   string       `json:"librarySectionTitle"`
+	LibrarySectionKey     string       `json:"librarySectionKey"`
+	RatingKey             string       `json:"ratingKey"`
+	Key                   string       `json:"key"`
+	ParentRatingKey       string       `json:"parentRatingKey"`
+	GrandparentRatingKey  string       `json:"grandparentRatingKey"`
+	GUID                  string       `json:"guid"`
+	ParentGUID            string       `json:"parentGuid"`
+	GrandparentGUID       string       `json:"grandparentGuid"`
+	AltGUIDs              []AltGUID    `json:"Guid"`
+	LibrarySectionID      int          `json:"librarySectionID"`
+	MediaType             string       `json:"type"`
+	Title                 string       `json:"title"`
+	TitleSort             string       `json:"titleSort"`
+	GrandparentKey        string       `json:"grandparentKey"`
+	ParentKey             string       `json:"parentKey"`
+	GrandparentTitle      string       `json:"grandparentTitle"`
+	ParentTitle           string       `json:"parentTitle"`
+	OriginalTitle         string       `json:"originalTitle"`
+	Studio                string       `json:"studio"`
+	ContentRating         string       `json:"contentRating"`
+	Summary               string       `json:"summary"`
+	Tagline               string       `json:"tagline"`
+	Index                 int          `json:"index"`
+	ParentIndex           int          `json:"parentIndex"`
+	RatingCount           int          `json:"ratingCount"`
+	Rating                float64      `json:"rating"`
+	AudienceRating        float64      `json:"audienceRating"`
+	UserRating            float64      `json:"userRating"`
+	ViewCount             int          `json:"viewCount"`
+	ViewOffset            int          `json:"viewOffset"`
+	LastViewedAt          int          `json:"lastViewedAt"`
+	Year                  int          `json:"year"`
+	Duration              int          `json:"duration"`
+	OriginallyAvailableAt string       `json:"originallyAvailableAt"`
+	Thumb                 string       `json:"thumb"`
+	Art                   string       `json:"art"`
+	ParentThumb           string       `json:"parentThumb"`
+	GrandparentThumb      string       `json:"grandparentThumb"`
+	GrandparentArt        string       `json:"grandparentArt"`
+	GrandparentTheme      string       `json:"grandparentTheme"`
+	AddedAt               int          `json:"addedAt"`
+	UpdatedAt             int          `json:"updatedAt"`
+	Genre                 []TaggedData `json:"Genre"`
+	Director              []TaggedData `json:"Director"`
+	Writer                []TaggedData `json:"Writer"`
+	Producer              []TaggedData `json:"Producer"`
+	Country               []TaggedData `json:"Country"`
+	Collection            []TaggedData `json:"Collection"`
+	Role                  []Role       `json:"Role"`
 }
 
 // WebhookEvents holds the actions for each webhook events
 type WebhookEvents struct {
-	events map[string]func(w Webhook)
+	events  map[string]func(w Webhook) error
+	onError func(err error)
+
+	secret       string
+	secretInPath bool
+	sources      []*net.IPNet
+	servers      map[string]bool
+
+	maxThumbnailSize int64
+	thumbnailSink    ThumbnailSink
+
+	dispatcher  *webhookDispatcher
+	deadLetters DeadLetterStore
+}
+
+// SetSecret requires requests to carry secret as the "secret" query parameter,
+// i.e. http://192.168.1.14:8080/webhook?secret={secret}
+func (wh *WebhookEvents) SetSecret(secret string) {
+	wh.secret = secret
+	wh.secretInPath = false
+}
+
+// SetPathSecret requires requests to carry secret as the last segment of the url path,
+// i.e. http://192.168.1.14:8080/webhook/{secret}
+func (wh *WebhookEvents) SetPathSecret(secret string) {
+	wh.secret = secret
+	wh.secretInPath = true
+}
+
+// AllowSources only accepts requests from the given networks, i.e. "192.168.1.0/24".
+// A single address is accepted as well. The source is the remote address of the connection
+func (wh *WebhookEvents) AllowSources(cidrs ...string) error {
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+
+			if ip == nil {
+				return fmt.Errorf(ErrorWebhook, "invalid source address "+cidr)
+			}
+
+			bits := 8 * net.IPv6len
+
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+
+			wh.sources = append(wh.sources, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+
+		if err != nil {
+			return fmt.Errorf(ErrorWebhook, err.Error())
+		}
+
+		wh.sources = append(wh.sources, network)
+	}
+
+	return nil
 }
 
-// Handler listens for plex webhooks and executes the corresponding function
+// AllowServers only accepts webhooks sent by the servers with the given machine identifiers. See GetMachineID
+func (wh *WebhookEvents) AllowServers(machineIDs ...string) {
+	if wh.servers == nil {
+		wh.servers = map[string]bool{}
+	}
+
+	for _, id := range machineIDs {
+		wh.servers[id] = true
+	}
+}
+
+// OnError receives the reason a webhook request was rejected, thumbnails that could not be
+// captured and handlers that failed
+func (wh *WebhookEvents) OnError(fn func(err error)) {
+	wh.onError = fn
+}
+
+// Handler listens for plex webhooks and executes the corresponding function.
+// It replies 405 to anything but a POST, 401 when the request fails the secret,
+// source or server checks and 400 when the payload can not be read. With StartDispatcher
+// it replies before the handlers run, or 503 when the dispatch queue is full
 func (wh *WebhookEvents) Handler(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(0); err != nil {
-		fmt.Printf("can not read form: %v", err)
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		wh.reject(w, http.StatusMethodNotAllowed, "method "+r.Method+" is not allowed")
 		return
 	}
 
-	var hookEvent Webhook
+	if !wh.allowedSource(r.RemoteAddr) {
+		wh.reject(w, http.StatusUnauthorized, "source "+r.RemoteAddr+" is not allowed")
+		return
+	}
+
+	if !wh.validSecret(r) {
+		wh.reject(w, http.StatusUnauthorized, "invalid secret")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, wh.maxBodySize())
+
+	if err := r.ParseMultipartForm(maxWebhookMemory); err != nil {
+		wh.reject(w, http.StatusBadRequest, "can not read form: "+err.Error())
+		return
+	}
+
+	defer r.MultipartForm.RemoveAll()
 
 	payload, hasPayload := r.MultipartForm.Value["payload"]
 
-	if hasPayload {
-		if err := json.Unmarshal([]byte(payload[0]), &hookEvent); err != nil {
-			fmt.Printf("can not parse json: %v", err)
+	if !hasPayload {
+		wh.reject(w, http.StatusBadRequest, "missing payload")
+		return
+	}
+
+	var hookEvent Webhook
+
+	if err := json.Unmarshal([]byte(payload[0]), &hookEvent); err != nil {
+		wh.reject(w, http.StatusBadRequest, "can not parse json: "+err.Error())
+		return
+	}
+
+	if wh.servers != nil && !wh.servers[hookEvent.Server.UUID] {
+		wh.reject(w, http.StatusUnauthorized, "unknown server "+hookEvent.Server.UUID)
+		return
+	}
+
+	// a thumbnail that can not be read does not fail the webhook
+	thumb, err := wh.readThumbnail(r.MultipartForm)
+
+	if err != nil {
+		wh.error(err)
+	}
+
+	hookEvent.Thumbnail = thumb
+
+	if wh.dispatcher != nil {
+		if !wh.dispatcher.enqueue(hookEvent) {
+			wh.reject(w, http.StatusServiceUnavailable, "dispatch queue is full")
 			return
 		}
 
-		fn, ok := wh.events[hookEvent.Event]
+		w.WriteHeader(http.StatusOK)
 
-		if !ok {
-			fmt.Printf("unknown event name: %v\n", hookEvent.Event)
-			return
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	wh.dispatch(hookEvent)
+}
+
+// dispatch saves the thumbnail of a webhook and runs its handlers
+func (wh *WebhookEvents) dispatch(hookEvent Webhook) bool {
+	if hookEvent.Thumbnail != nil && wh.thumbnailSink != nil {
+		if err := wh.thumbnailSink.SaveThumbnail(hookEvent, *hookEvent.Thumbnail); err != nil {
+			wh.error(err)
 		}
+	}
+
+	ok := true
 
-		fn(hookEvent)
+	for _, eventName := range []string{hookEvent.Event, WebhookAnyEvent} {
+		if fn, registered := wh.events[eventName]; registered {
+			if !wh.run(eventName, fn, hookEvent) {
+				ok = false
+			}
+		}
 	}
+
+	return ok
+}
+
+func (wh *WebhookEvents) reject(w http.ResponseWriter, statusCode int, reason string) {
+	wh.error(fmt.Errorf(ErrorWebhook, reason))
+
+	http.Error(w, http.StatusText(statusCode), statusCode)
+}
+
+func (wh *WebhookEvents) error(err error) {
+	if wh.onError != nil {
+		wh.onError(err)
+	}
+}
+
+func (wh *WebhookEvents) allowedSource(remoteAddr string) bool {
+	if len(wh.sources) == 0 {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range wh.sources {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maxBodySize is the largest request read, so a caller can not fill the disk with a form
+func (wh *WebhookEvents) maxBodySize() int64 {
+	if size := wh.maxThumbnailSize + maxWebhookPayload; size > maxWebhookBody {
+		return size
+	}
+
+	return maxWebhookBody
+}
+
+func (wh *WebhookEvents) validSecret(r *http.Request) bool {
+	if wh.secret == "" {
+		return true
+	}
+
+	secret := r.URL.Query().Get("secret")
+
+	if wh.secretInPath {
+		secret = path.Base(r.URL.Path)
+	}
+
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(wh.secret)) == 1
 }
 
 // newWebhookEvent attaches a function to each webhook event
 func (wh *WebhookEvents) newWebhookEvent(eventName string, onEvent func(w Webhook)) error {
-	switch eventName {
-	case "media.play":
-	case "media.pause":
-	case "media.resume":
-	case "media.stop":
-	case "media.scrobble":
-	case "media.rate":
-
-	default:
+	return wh.OnWithError(eventName, func(w Webhook) error {
+		onEvent(w)
+		return nil
+	})
+}
+
+// OnWithError is like On but fn can fail. Failed webhooks are added to the dead-letter store
+func (wh *WebhookEvents) OnWithError(eventName string, fn func(w Webhook) error) error {
+	if !webhookEventNames[eventName] {
 		return errors.New("invalid event name")
 	}
 
-	wh.events[eventName] = onEvent
+	wh.events[eventName] = fn
 
 	return nil
 }
@@ -110,43 +419,79 @@ func (wh *WebhookEvents) newWebhookEvent(eventName string, onEvent func(w Webhoo
 // NewWebhook inits and returns a webhook event
 func NewWebhook() *WebhookEvents {
 	return &WebhookEvents{
-		events: map[string]func(w Webhook){
-			"media.play":     func(w Webhook) {},
-			"media.pause":    func(w Webhook) {},
-			"media.resume":   func(w Webhook) {},
-			"media.stop":     func(w Webhook) {},
-			"media.scrobble": func(w Webhook) {},
-			"media.rate":     func(w Webhook) {},
-		},
+		events:           map[string]func(w Webhook) error{},
+		maxThumbnailSize: defaultMaxThumbnailSize,
+		deadLetters:      NewMemoryDeadLetterStore(defaultDeadLetterCapacity),
 	}
 }
 
+// On executes fn when the webhook receives eventName, one of the Webhook constants.
+// WebhookAnyEvent receives every event after its own handler
+func (wh *WebhookEvents) On(eventName string, fn func(w Webhook)) error {
+	return wh.newWebhookEvent(eventName, fn)
+}
+
+// OnAny executes when the webhook receives any event
+func (wh *WebhookEvents) OnAny(fn func(w Webhook)) error {
+	return wh.newWebhookEvent(WebhookAnyEvent, fn)
+}
+
 // OnPlay executes when the webhook receives a play event
 func (wh *WebhookEvents) OnPlay(fn func(w Webhook)) error {
-	return wh.newWebhookEvent("media.play", fn)
+	return wh.newWebhookEvent(WebhookMediaPlay, fn)
 }
 
 // OnPause executes when the webhook receives a pause event
 func (wh *WebhookEvents) OnPause(fn func(w Webhook)) error {
-	return wh.newWebhookEvent("media.pause", fn)
+	return wh.newWebhookEvent(WebhookMediaPause, fn)
 }
 
 // OnResume executes when the webhook receives a resume event
 func (wh *WebhookEvents) OnResume(fn func(w Webhook)) error {
-	return wh.newWebhookEvent("media.resume", fn)
+	return wh.newWebhookEvent(WebhookMediaResume, fn)
 }
 
 // OnStop executes when the webhook receives a stop event
 func (wh *WebhookEvents) OnStop(fn func(w Webhook)) error {
-	return wh.newWebhookEvent("media.stop", fn)
+	return wh.newWebhookEvent(WebhookMediaStop, fn)
 }
 
 // OnScrobble executes when the webhook receives a scrobble event
 func (wh *WebhookEvents) OnScrobble(fn func(w Webhook)) error {
-	return wh.newWebhookEvent("media.scrobble", fn)
+	return wh.newWebhookEvent(WebhookMediaScrobble, fn)
 }
 
 // OnRate executes when the webhook receives a rate event
 func (wh *WebhookEvents) OnRate(fn func(w Webhook)) error {
-	return wh.newWebhookEvent("media.rate", fn)
+	return wh.newWebhookEvent(WebhookMediaRate, fn)
+}
+
+// OnLibraryNew executes when new media is added to a library
+func (wh *WebhookEvents) OnLibraryNew(fn func(w Webhook)) error {
+	return wh.newWebhookEvent(WebhookLibraryNew, fn)
+}
+
+// OnLibraryOnDeck executes when media is added to the on deck
+func (wh *WebhookEvents) OnLibraryOnDeck(fn func(w Webhook)) error {
+	return wh.newWebhookEvent(WebhookLibraryOnDeck, fn)
+}
+
+// OnDatabaseBackup executes when the server completed a database backup
+func (wh *WebhookEvents) OnDatabaseBackup(fn func(w Webhook)) error {
+	return wh.newWebhookEvent(WebhookAdminDatabaseBackup, fn)
+}
+
+// OnDatabaseCorrupted executes when the server detected a corrupted database
+func (wh *WebhookEvents) OnDatabaseCorrupted(fn func(w Webhook)) error {
+	return wh.newWebhookEvent(WebhookAdminDatabaseCorrupted, fn)
+}
+
+// OnDeviceNew executes when a device connects to the server for the first time
+func (wh *WebhookEvents) OnDeviceNew(fn func(w Webhook)) error {
+	return wh.newWebhookEvent(WebhookDeviceNew, fn)
+}
+
+// OnPlaybackStarted executes when a shared user starts playing media
+func (wh *WebhookEvents) OnPlaybackStarted(fn func(w Webhook)) error {
+	return wh.newWebhookEvent(WebhookPlaybackStarted, fn)
 }
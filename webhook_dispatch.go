@@ -0,0 +1,281 @@
+package plex
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultDispatchWorkers    = 4
+	defaultDispatchQueueSize  = 100
+	defaultDeadLetterCapacity = 100
+)
+
+// DispatchOptions configures the asynchronous dispatch of webhooks
+type DispatchOptions struct {
+	// Workers is the number of goroutines running handlers. Defaults to 4
+	Workers int
+	// QueueSize is the number of webhooks each worker buffers. When the queue of a worker
+	// is full the webhook is answered with 503 so Plex retries it. Defaults to 100
+	QueueSize int
+	// OrderKey groups webhooks that are handled in the order they were received, by the same
+	// worker. Defaults to the player, so the events of a playback arrive in order
+	OrderKey func(w Webhook) string
+}
+
+// DispatchStats are the metrics of the webhook dispatcher
+type DispatchStats struct {
+	// Pending is the number of queued webhooks across all workers
+	Pending int
+	// Capacity is the total size of the queues
+	Capacity int
+	// Processed is the number of webhooks whose handlers ran
+	Processed uint64
+	// Failed is the number of webhooks with a handler that returned an error or panicked
+	Failed uint64
+	// Rejected is the number of webhooks refused because the queue was full
+	Rejected uint64
+}
+
+// DeadLetter is a webhook whose handler failed
+type DeadLetter struct {
+	Webhook Webhook
+	// Handler is the event the failed handler is registered for, i.e. media.play or *
+	Handler  string
+	Err      error
+	FailedAt time.Time
+}
+
+// DeadLetterStore keeps webhooks whose handler failed
+type DeadLetterStore interface {
+	Add(letter DeadLetter) error
+	List() []DeadLetter
+}
+
+// MemoryDeadLetterStore keeps the most recent dead letters in memory
+type MemoryDeadLetterStore struct {
+	mu       sync.Mutex
+	capacity int
+	letters  []DeadLetter
+}
+
+// NewMemoryDeadLetterStore creates a store that keeps up to capacity dead letters, dropping the oldest
+func NewMemoryDeadLetterStore(capacity int) *MemoryDeadLetterStore {
+	return &MemoryDeadLetterStore{capacity: capacity}
+}
+
+// Add stores a dead letter
+func (s *MemoryDeadLetterStore) Add(letter DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.letters = append(s.letters, letter)
+
+	if s.capacity > 0 && len(s.letters) > s.capacity {
+		s.letters = s.letters[len(s.letters)-s.capacity:]
+	}
+
+	return nil
+}
+
+// List returns the stored dead letters, oldest first
+func (s *MemoryDeadLetterStore) List() []DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]DeadLetter(nil), s.letters...)
+}
+
+type webhookDispatcher struct {
+	processed uint64
+	failed    uint64
+	rejected  uint64
+
+	queues   []chan Webhook
+	orderKey func(w Webhook) string
+	wg       sync.WaitGroup
+
+	// mu guards closing the queues while webhooks are enqueued
+	mu     sync.RWMutex
+	closed bool
+}
+
+// SetDeadLetterStore replaces the store of failed webhooks. Defaults to an in-memory store of the last 100
+func (wh *WebhookEvents) SetDeadLetterStore(store DeadLetterStore) {
+	wh.deadLetters = store
+}
+
+// DeadLetters returns the store of failed webhooks
+func (wh *WebhookEvents) DeadLetters() DeadLetterStore {
+	return wh.deadLetters
+}
+
+// StartDispatcher makes Handler acknowledge webhooks right away and run the handlers on a pool
+// of workers. Register handlers before starting it and call StopDispatcher to drain the queues
+func (wh *WebhookEvents) StartDispatcher(opts DispatchOptions) {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultDispatchWorkers
+	}
+
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultDispatchQueueSize
+	}
+
+	if opts.OrderKey == nil {
+		opts.OrderKey = playerOrderKey
+	}
+
+	d := &webhookDispatcher{
+		queues:   make([]chan Webhook, opts.Workers),
+		orderKey: opts.OrderKey,
+	}
+
+	for i := range d.queues {
+		d.queues[i] = make(chan Webhook, opts.QueueSize)
+
+		d.wg.Add(1)
+
+		go func(queue chan Webhook) {
+			defer d.wg.Done()
+
+			for hookEvent := range queue {
+				if wh.dispatch(hookEvent) {
+					atomic.AddUint64(&d.processed, 1)
+				} else {
+					atomic.AddUint64(&d.failed, 1)
+				}
+			}
+		}(d.queues[i])
+	}
+
+	wh.dispatcher = d
+}
+
+// StopDispatcher stops accepting webhooks and waits until the queued ones are handled or ctx is done
+func (wh *WebhookEvents) StopDispatcher(ctx context.Context) error {
+	d := wh.dispatcher
+
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+
+	if !d.closed {
+		d.closed = true
+
+		for _, queue := range d.queues {
+			close(queue)
+		}
+	}
+
+	d.mu.Unlock()
+
+	drained := make(chan struct{})
+
+	go func() {
+		d.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DispatchStats returns the metrics of the dispatcher. It is empty unless StartDispatcher was called
+func (wh *WebhookEvents) DispatchStats() DispatchStats {
+	d := wh.dispatcher
+
+	if d == nil {
+		return DispatchStats{}
+	}
+
+	stats := DispatchStats{
+		Processed: atomic.LoadUint64(&d.processed),
+		Failed:    atomic.LoadUint64(&d.failed),
+		Rejected:  atomic.LoadUint64(&d.rejected),
+	}
+
+	for _, queue := range d.queues {
+		stats.Pending += len(queue)
+		stats.Capacity += cap(queue)
+	}
+
+	return stats
+}
+
+// enqueue hands a webhook to the worker of its order key and reports whether it was accepted
+func (d *webhookDispatcher) enqueue(hookEvent Webhook) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		atomic.AddUint64(&d.rejected, 1)
+		return false
+	}
+
+	hash := fnv.New32a()
+
+	hash.Write([]byte(d.orderKey(hookEvent)))
+
+	select {
+	case d.queues[hash.Sum32()%uint32(len(d.queues))] <- hookEvent:
+		return true
+	default:
+		atomic.AddUint64(&d.rejected, 1)
+		return false
+	}
+}
+
+// run calls a handler, recovering from a panic, and records a dead letter when it fails
+func (wh *WebhookEvents) run(eventName string, fn func(w Webhook) error, hookEvent Webhook) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			wh.deadLetter(eventName, hookEvent, fmt.Errorf("handler panicked: %v\n%s", r, debug.Stack()))
+			ok = false
+		}
+	}()
+
+	if err := fn(hookEvent); err != nil {
+		wh.deadLetter(eventName, hookEvent, err)
+		return false
+	}
+
+	return true
+}
+
+func (wh *WebhookEvents) deadLetter(eventName string, hookEvent Webhook, err error) {
+	wh.error(fmt.Errorf(ErrorWebhook, fmt.Sprintf("%s handler failed: %v", eventName, err)))
+
+	if wh.deadLetters == nil {
+		return
+	}
+
+	letter := DeadLetter{
+		Webhook:  hookEvent,
+		Handler:  eventName,
+		Err:      err,
+		FailedAt: time.Now(),
+	}
+
+	if err := wh.deadLetters.Add(letter); err != nil {
+		wh.error(err)
+	}
+}
+
+func playerOrderKey(w Webhook) string {
+	if w.Player.UUID != "" {
+		return w.Player.UUID
+	}
+
+	return w.Server.UUID
+}
@@ -0,0 +1,137 @@
+package plex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookDispatcher(t *testing.T) {
+	wh := NewWebhook()
+
+	var (
+		mu     sync.Mutex
+		titles = map[string][]string{}
+	)
+
+	wh.OnPlay(func(w Webhook) {
+		// a slow handler must not delay the response
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		titles[w.Player.UUID] = append(titles[w.Player.UUID], w.Metadata.Title)
+		mu.Unlock()
+	})
+
+	wh.OnWithError(WebhookMediaStop, func(w Webhook) error {
+		return errors.New("notifier is down")
+	})
+
+	wh.OnPause(func(w Webhook) {
+		panic("boom")
+	})
+
+	wh.StartDispatcher(DispatchOptions{Workers: 3})
+
+	for i := 0; i < 10; i++ {
+		payload := fmt.Sprintf(`{"event":"media.play","Player":{"uuid":"player-%d"},"Metadata":{"title":"%d"}}`, i%2, i)
+
+		w := httptest.NewRecorder()
+
+		wh.Handler(w, newWebhookRequest(t, "/", payload))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+	}
+
+	wh.Handler(httptest.NewRecorder(), newWebhookRequest(t, "/", `{"event":"media.stop","Player":{"uuid":"player-0"}}`))
+	wh.Handler(httptest.NewRecorder(), newWebhookRequest(t, "/", `{"event":"media.pause","Player":{"uuid":"player-1"}}`))
+
+	if err := wh.StopDispatcher(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// events of the same player are handled in order
+	expected := map[string][]string{
+		"player-0": {"0", "2", "4", "6", "8"},
+		"player-1": {"1", "3", "5", "7", "9"},
+	}
+
+	for player, want := range expected {
+		if fmt.Sprint(titles[player]) != fmt.Sprint(want) {
+			t.Errorf("expected %s to receive %v, got %v", player, want, titles[player])
+		}
+	}
+
+	stats := wh.DispatchStats()
+
+	if stats.Processed != 10 || stats.Failed != 2 || stats.Pending != 0 || stats.Capacity != 3*defaultDispatchQueueSize {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	letters := wh.DeadLetters().List()
+
+	if len(letters) != 2 {
+		t.Fatalf("expected 2 dead letters, got %+v", letters)
+	}
+
+	for _, letter := range letters {
+		if letter.Handler != letter.Webhook.Event || letter.Err == nil {
+			t.Errorf("unexpected dead letter: %+v", letter)
+		}
+	}
+
+	// a stopped dispatcher refuses webhooks
+	w := httptest.NewRecorder()
+
+	wh.Handler(w, newWebhookRequest(t, "/", testWebhookPlay))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+}
+
+func TestWebhookDispatcherQueueFull(t *testing.T) {
+	wh := NewWebhook()
+
+	release := make(chan struct{})
+
+	wh.OnPlay(func(w Webhook) {
+		<-release
+	})
+
+	wh.StartDispatcher(DispatchOptions{Workers: 1, QueueSize: 1})
+
+	codes := make([]int, 3)
+
+	for i := range codes {
+		w := httptest.NewRecorder()
+
+		wh.Handler(w, newWebhookRequest(t, "/", testWebhookPlay))
+
+		codes[i] = w.Code
+
+		// let the worker pick up the first webhook
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	close(release)
+
+	if err := wh.StopDispatcher(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusServiceUnavailable {
+		t.Errorf("expected the third webhook to be refused, got %v", codes)
+	}
+
+	if stats := wh.DispatchStats(); stats.Rejected != 1 || stats.Processed != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,238 @@
+package plex
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testWebhookPlay = `{"event":"media.play","user":true,"owner":true,"Account":{"id":1,"title":"walter"},"Server":{"title":"Office","uuid":"abc123"},"Player":{"local":true,"title":"Living Room","uuid":"tv-1"},"Metadata":{"librarySectionType":"show","ratingKey":"10","key":"/library/metadata/10","type":"episode","title":"Pilot","grandparentTitle":"Breaking Bad"}}`
+
+func newWebhookRequest(t *testing.T, target, payload string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+
+	form := multipart.NewWriter(&body)
+
+	if err := form.WriteField("payload", payload); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, target, &body)
+
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	r.RemoteAddr = "192.168.1.20:32400"
+
+	return r
+}
+
+func TestWebhookHandler(t *testing.T) {
+	wh := NewWebhook()
+
+	var played []string
+
+	wh.OnPlay(func(w Webhook) {
+		played = append(played, w.Metadata.Title)
+	})
+
+	w := httptest.NewRecorder()
+
+	wh.Handler(w, newWebhookRequest(t, "/", testWebhookPlay))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	if len(played) != 1 || played[0] != "Pilot" {
+		t.Errorf("expected the play handler to receive Pilot, got %v", played)
+	}
+}
+
+func TestWebhookHandlerValidation(t *testing.T) {
+	wh := NewWebhook()
+
+	wh.SetSecret("s3cr3t")
+	wh.AllowServers("abc123")
+
+	if err := wh.AllowSources("192.168.1.0/24", "::1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wh.AllowSources("not-an-address"); err == nil {
+		t.Error("expected an error for an invalid source")
+	}
+
+	var (
+		calls  int
+		errors int
+	)
+
+	wh.OnPlay(func(w Webhook) { calls++ })
+	wh.OnError(func(err error) { errors++ })
+
+	outsider := newWebhookRequest(t, "/webhook?secret=s3cr3t", testWebhookPlay)
+	outsider.RemoteAddr = "10.0.0.5:32400"
+
+	notMultipart := httptest.NewRequest(http.MethodPost, "/webhook?secret=s3cr3t", bytes.NewBufferString(testWebhookPlay))
+	notMultipart.RemoteAddr = "192.168.1.20:32400"
+
+	testCases := []struct {
+		name       string
+		request    *http.Request
+		statusCode int
+	}{
+		{"query secret", newWebhookRequest(t, "/webhook?secret=s3cr3t", testWebhookPlay), http.StatusOK},
+		{"wrong secret", newWebhookRequest(t, "/webhook?secret=guess", testWebhookPlay), http.StatusUnauthorized},
+		{"secret in path", newWebhookRequest(t, "/webhook/s3cr3t", testWebhookPlay), http.StatusUnauthorized},
+		{"outside source", outsider, http.StatusUnauthorized},
+		{"unknown server", newWebhookRequest(t, "/webhook?secret=s3cr3t", `{"event":"media.play","Server":{"uuid":"other"}}`), http.StatusUnauthorized},
+		{"malformed payload", newWebhookRequest(t, "/webhook?secret=s3cr3t", `{"event":`), http.StatusBadRequest},
+		{"not multipart", notMultipart, http.StatusBadRequest},
+		{"wrong method", httptest.NewRequest(http.MethodGet, "/webhook?secret=s3cr3t", nil), http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+
+		wh.Handler(w, tc.request)
+
+		if w.Code != tc.statusCode {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.statusCode, w.Code)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("expected 1 accepted webhook, got %d", calls)
+	}
+
+	if errors != len(testCases)-1 {
+		t.Errorf("expected %d rejections, got %d", len(testCases)-1, errors)
+	}
+}
+
+func TestWebhookPathSecret(t *testing.T) {
+	wh := NewWebhook()
+
+	wh.SetPathSecret("s3cr3t")
+
+	testCases := []struct {
+		target     string
+		statusCode int
+	}{
+		{"/webhook/s3cr3t", http.StatusOK},
+		{"/webhook/guess", http.StatusUnauthorized},
+		{"/webhook?secret=s3cr3t", http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+
+		wh.Handler(w, newWebhookRequest(t, tc.target, testWebhookPlay))
+
+		if w.Code != tc.statusCode {
+			t.Errorf("%s: expected status %d, got %d", tc.target, tc.statusCode, w.Code)
+		}
+	}
+}
+
+func TestWebhookBodyLimit(t *testing.T) {
+	wh := NewWebhook()
+
+	called := false
+
+	wh.OnPlay(func(w Webhook) { called = true })
+
+	// a valid webhook with a thumbnail larger than any request that is read
+	body := io.MultiReader(
+		strings.NewReader("--b\r\nContent-Disposition: form-data; name=\"payload\"\r\n\r\n"+testWebhookPlay+"\r\n"),
+		strings.NewReader("--b\r\nContent-Disposition: form-data; name=\"thumb\"; filename=\"thumb.jpg\"\r\nContent-Type: image/jpeg\r\n\r\n"),
+		io.LimitReader(neverEnding('x'), maxWebhookBody),
+		strings.NewReader("\r\n--b--\r\n"),
+	)
+
+	r := httptest.NewRequest(http.MethodPost, "/webhook", body)
+
+	r.Header.Set("Content-Type", "multipart/form-data; boundary=b")
+
+	w := httptest.NewRecorder()
+
+	wh.Handler(w, r)
+
+	if w.Code != http.StatusBadRequest || called {
+		t.Errorf("expected an oversized request to be rejected, got %d", w.Code)
+	}
+}
+
+// neverEnding reads as an endless repetition of a byte
+type neverEnding byte
+
+func (b neverEnding) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(b)
+	}
+
+	return len(p), nil
+}
+
+func TestWebhookEvents(t *testing.T) {
+	wh := NewWebhook()
+
+	var (
+		devices []string
+		added   []string
+		all     []string
+	)
+
+	if err := wh.OnDeviceNew(func(w Webhook) { devices = append(devices, w.Device.Name) }); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wh.On(WebhookLibraryNew, func(w Webhook) { added = append(added, w.Metadata.Genre[0].Tag) }); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wh.OnAny(func(w Webhook) { all = append(all, w.Event) }); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wh.On("media.unknown", func(w Webhook) {}); err == nil {
+		t.Error("expected an error registering an unknown event")
+	}
+
+	payloads := []string{
+		`{"event":"device.new","Account":{"id":1,"title":"walter"},"Device":{"id":5,"name":"Jesse's iPhone","platform":"iOS","clientIdentifier":"iphone-1"}}`,
+		`{"event":"library.new","Metadata":{"ratingKey":"12","type":"movie","title":"El Camino","year":2019,"Genre":[{"id":7,"tag":"Crime"}],"Role":[{"id":9,"tag":"Aaron Paul","role":"Jesse Pinkman"}]}}`,
+		`{"event":"admin.database.backup"}`,
+	}
+
+	for _, payload := range payloads {
+		w := httptest.NewRecorder()
+
+		wh.Handler(w, newWebhookRequest(t, "/", payload))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+	}
+
+	if len(devices) != 1 || devices[0] != "Jesse's iPhone" {
+		t.Errorf("expected the new device, got %v", devices)
+	}
+
+	if len(added) != 1 || added[0] != "Crime" {
+		t.Errorf("expected the new movie's genre, got %v", added)
+	}
+
+	if len(all) != 3 || all[2] != WebhookAdminDatabaseBackup {
+		t.Errorf("expected the wildcard handler to receive every event, got %v", all)
+	}
+}
@@ -0,0 +1,145 @@
+package plex
+
+import (
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMaxThumbnailSize is the largest thumbnail a webhook captures unless changed with SetMaxThumbnailSize
+const defaultMaxThumbnailSize = 5 << 20
+
+// WebhookThumbnail is the poster Plex attaches to webhooks about media
+type WebhookThumbnail struct {
+	Data        []byte
+	ContentType string
+	Filename    string
+}
+
+// ThumbnailSink persists the thumbnails of webhooks
+type ThumbnailSink interface {
+	SaveThumbnail(w Webhook, thumb WebhookThumbnail) error
+}
+
+// ThumbnailSinkFunc adapts a function to a ThumbnailSink
+type ThumbnailSinkFunc func(w Webhook, thumb WebhookThumbnail) error
+
+// SaveThumbnail calls fn
+func (fn ThumbnailSinkFunc) SaveThumbnail(w Webhook, thumb WebhookThumbnail) error {
+	return fn(w, thumb)
+}
+
+// DirThumbnailSink writes thumbnails to a directory, named after the rating key of the media
+type DirThumbnailSink struct {
+	Dir string
+}
+
+// SaveThumbnail writes the thumbnail to {Dir}/{ratingKey}{ext}. Thumbnails of webhooks without
+// a numeric rating key are named after the current time
+func (d DirThumbnailSink) SaveThumbnail(w Webhook, thumb WebhookThumbnail) error {
+	name := w.Metadata.RatingKey
+
+	// the payload is untrusted, a key like ../../.bashrc must not escape Dir
+	if !isRatingKey(name) {
+		name = strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+
+	if err := os.MkdirAll(d.Dir, 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(d.Dir, name+thumbnailExtension(thumb.ContentType)), thumb.Data, 0644)
+}
+
+// isRatingKey reports whether key is a rating key, which are numeric
+func isRatingKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for _, c := range key {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SetMaxThumbnailSize sets the largest thumbnail captured, in bytes. Larger thumbnails are
+// dropped and reported to OnError. A size of 0 or less disables capturing. Defaults to 5MB
+func (wh *WebhookEvents) SetMaxThumbnailSize(size int64) {
+	wh.maxThumbnailSize = size
+}
+
+// SetThumbnailSink persists the thumbnail of every webhook that has one, before the event handlers run
+func (wh *WebhookEvents) SetThumbnailSink(sink ThumbnailSink) {
+	wh.thumbnailSink = sink
+}
+
+// readThumbnail returns the "thumb" part of a webhook, if any
+func (wh *WebhookEvents) readThumbnail(form *multipart.Form) (*WebhookThumbnail, error) {
+	files := form.File["thumb"]
+
+	if len(files) == 0 || wh.maxThumbnailSize <= 0 {
+		return nil, nil
+	}
+
+	header := files[0]
+
+	if header.Size > wh.maxThumbnailSize {
+		return nil, fmt.Errorf(ErrorWebhook, fmt.Sprintf("thumbnail of %d bytes exceeds the limit of %d bytes", header.Size, wh.maxThumbnailSize))
+	}
+
+	file, err := header.Open()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	data, err := ioutil.ReadAll(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := header.Header.Get("Content-Type")
+
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(data)
+	}
+
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf(ErrorWebhook, "thumbnail has unexpected content type "+contentType)
+	}
+
+	return &WebhookThumbnail{
+		Data:        data,
+		ContentType: contentType,
+		Filename:    header.Filename,
+	}, nil
+}
+
+func thumbnailExtension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	}
+
+	if extensions, err := mime.ExtensionsByType(contentType); err == nil && len(extensions) > 0 {
+		return extensions[0]
+	}
+
+	return ""
+}
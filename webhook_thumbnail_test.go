@@ -0,0 +1,167 @@
+package plex
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testJPEG is the start of a jpeg file, enough for content sniffing
+var testJPEG = []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")
+
+func newWebhookRequestWithThumb(t *testing.T, payload, contentType string, thumb []byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+
+	form := multipart.NewWriter(&body)
+
+	if err := form.WriteField("payload", payload); err != nil {
+		t.Fatal(err)
+	}
+
+	header := textproto.MIMEHeader{}
+
+	header.Set("Content-Disposition", `form-data; name="thumb"; filename="thumb.jpg"`)
+	header.Set("Content-Type", contentType)
+
+	part, err := form.CreatePart(header)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	part.Write(thumb)
+
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", &body)
+
+	r.Header.Set("Content-Type", form.FormDataContentType())
+
+	return r
+}
+
+func TestWebhookThumbnail(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plex-thumbs")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	wh := NewWebhook()
+
+	wh.SetThumbnailSink(DirThumbnailSink{Dir: dir})
+
+	var thumb *WebhookThumbnail
+
+	wh.OnPlay(func(w Webhook) {
+		thumb = w.Thumbnail
+	})
+
+	w := httptest.NewRecorder()
+
+	wh.Handler(w, newWebhookRequestWithThumb(t, testWebhookPlay, "application/octet-stream", testJPEG))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	if thumb == nil {
+		t.Fatal("expected a thumbnail")
+	}
+
+	if thumb.ContentType != "image/jpeg" || !bytes.Equal(thumb.Data, testJPEG) || thumb.Filename != "thumb.jpg" {
+		t.Errorf("unexpected thumbnail: %s %s %d bytes", thumb.Filename, thumb.ContentType, len(thumb.Data))
+	}
+
+	saved, err := ioutil.ReadFile(filepath.Join(dir, "10.jpg"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(saved, testJPEG) {
+		t.Error("expected the sink to write the thumbnail")
+	}
+}
+
+func TestWebhookThumbnailTraversal(t *testing.T) {
+	root, err := ioutil.TempDir("", "plex-thumbs")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "thumbs")
+
+	wh := NewWebhook()
+
+	wh.SetThumbnailSink(DirThumbnailSink{Dir: dir})
+
+	payload := strings.Replace(testWebhookPlay, `"ratingKey":"10"`, `"ratingKey":"../escaped"`, 1)
+
+	wh.Handler(httptest.NewRecorder(), newWebhookRequestWithThumb(t, payload, "image/jpeg", testJPEG))
+
+	if _, err := os.Stat(filepath.Join(root, "escaped.jpg")); !os.IsNotExist(err) {
+		t.Fatal("expected the thumbnail to stay in the sink directory")
+	}
+
+	files, err := ioutil.ReadDir(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 || files[0].Name() == "escaped.jpg" {
+		t.Errorf("expected a thumbnail named after the time, got %v", files)
+	}
+}
+
+func TestWebhookThumbnailRejected(t *testing.T) {
+	wh := NewWebhook()
+
+	wh.SetMaxThumbnailSize(8)
+
+	var (
+		calls    int
+		rejected int
+	)
+
+	wh.OnPlay(func(w Webhook) {
+		calls++
+
+		if w.Thumbnail != nil {
+			t.Error("expected the thumbnail to be dropped")
+		}
+	})
+
+	wh.OnError(func(err error) { rejected++ })
+
+	wh.Handler(httptest.NewRecorder(), newWebhookRequestWithThumb(t, testWebhookPlay, "image/jpeg", testJPEG))
+
+	wh.SetMaxThumbnailSize(defaultMaxThumbnailSize)
+
+	wh.Handler(httptest.NewRecorder(), newWebhookRequestWithThumb(t, testWebhookPlay, "text/html", []byte("<html></html>")))
+
+	if calls != 2 {
+		t.Errorf("expected both webhooks to be handled, got %d", calls)
+	}
+
+	if rejected != 2 {
+		t.Errorf("expected 2 rejected thumbnails, got %d", rejected)
+	}
+}
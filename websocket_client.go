@@ -1,11 +1,14 @@
 package plex
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -91,6 +94,18 @@ type TranscodeSession struct {
 	VideoDecision        string  `json:"videoDecision"`
 }
 
+// AutoUpdateNotification reports the state of a server update
+type AutoUpdateNotification struct {
+	Key     string `json:"key"`
+	State   string `json:"state"`
+	Version string `json:"version"`
+}
+
+// ProgressNotification reports the progress of a server task such as a database optimization
+type ProgressNotification struct {
+	Message string `json:"message"`
+}
+
 // Setting ...
 type Setting struct {
 	Advanced bool   `json:"advanced"`
@@ -122,20 +137,32 @@ type NotificationContainer struct {
 
 	Setting []Setting `json:"Setting"`
 
+	AutoUpdateNotification []AutoUpdateNotification `json:"AutoUpdateNotification"`
+
+	ProgressNotification []ProgressNotification `json:"ProgressNotification"`
+
 	Size int64 `json:"size"`
-	// Type can be one of:
-	// playing,
-	// reachability,
-	// transcode.end,
-	// preference,
-	// update.statechange,
-	// activity,
-	// backgroundProcessingQueue,
-	// transcodeSession.update
-	// transcodeSession.end
+	// Type is one of the Notification constants, i.e. NotificationPlaying
 	Type string `json:"type"`
 }
 
+// Notification types sent by a plex media server
+const (
+	NotificationPlaying                   = "playing"
+	NotificationActivity                  = "activity"
+	NotificationTimeline                  = "timeline"
+	NotificationStatus                    = "status"
+	NotificationProgress                  = "progress"
+	NotificationReachability              = "reachability"
+	NotificationPreference                = "preference"
+	NotificationBackgroundProcessingQueue = "backgroundProcessingQueue"
+	NotificationUpdateStateChange         = "update.statechange"
+	NotificationTranscodeEnd              = "transcode.end"
+	NotificationTranscodeSessionStart     = "transcodeSession.start"
+	NotificationTranscodeSessionUpdate    = "transcodeSession.update"
+	NotificationTranscodeSessionEnd       = "transcodeSession.end"
+)
+
 // WebsocketNotification websocket payload of notifications from a plex media server
 type WebsocketNotification struct {
 	NotificationContainer `json:"NotificationContainer"`
@@ -144,6 +171,10 @@ type WebsocketNotification struct {
 // NotificationEvents hold callbacks that correspond to notifications
 type NotificationEvents struct {
 	events map[string]func(n NotificationContainer)
+	typed  map[string]func(n NotificationContainer)
+	raw    func(notificationType string, message []byte)
+	// all receives every decoded notification, used by EventStream
+	all func(n NotificationContainer)
 }
 
 // NewNotificationEvents initializes the event callbacks
@@ -160,6 +191,7 @@ func NewNotificationEvents() *NotificationEvents {
 			"activity":                  func(n NotificationContainer) {},
 			"backgroundProcessingQueue": func(n NotificationContainer) {},
 		},
+		typed: map[string]func(n NotificationContainer){},
 	}
 }
 
@@ -173,95 +205,385 @@ func (e *NotificationEvents) OnTranscodeUpdate(fn func(n NotificationContainer))
 	e.events["transcodeSession.update"] = fn
 }
 
-// SubscribeToNotifications connects to your server via websockets listening for events
+// OnRaw is called with the undecoded payload of every notification, including types
+// that have no typed handler
+func (e *NotificationEvents) OnRaw(fn func(notificationType string, message []byte)) {
+	e.raw = fn
+}
+
+// OnPlaySessionState is called when a user starts, pauses, resumes, stops or progresses through media
+func (e *NotificationEvents) OnPlaySessionState(fn func(n []PlaySessionStateNotification)) {
+	e.typed[NotificationPlaying] = func(n NotificationContainer) { fn(n.PlaySessionStateNotification) }
+}
+
+// OnActivity is called when a server activity such as a library scan starts, progresses or ends
+func (e *NotificationEvents) OnActivity(fn func(n []ActivityNotification)) {
+	e.typed[NotificationActivity] = func(n NotificationContainer) { fn(n.ActivityNotification) }
+}
+
+// OnTimeline is called when library items are added, updated or deleted
+func (e *NotificationEvents) OnTimeline(fn func(n []TimelineEntry)) {
+	e.typed[NotificationTimeline] = func(n NotificationContainer) { fn(n.TimelineEntry) }
+}
+
+// OnStatus is called for server status messages such as a library scan finishing
+func (e *NotificationEvents) OnStatus(fn func(n []StatusNotification)) {
+	e.typed[NotificationStatus] = func(n NotificationContainer) { fn(n.StatusNotification) }
+}
+
+// OnProgress is called with progress messages of server tasks
+func (e *NotificationEvents) OnProgress(fn func(n []ProgressNotification)) {
+	e.typed[NotificationProgress] = func(n NotificationContainer) { fn(n.ProgressNotification) }
+}
+
+// OnReachability is called when the remote access reachability of the server changes
+func (e *NotificationEvents) OnReachability(fn func(n []ReachabilityNotification)) {
+	e.typed[NotificationReachability] = func(n NotificationContainer) { fn(n.ReachabilityNotification) }
+}
+
+// OnPreference is called when a server setting changes
+func (e *NotificationEvents) OnPreference(fn func(n []Setting)) {
+	e.typed[NotificationPreference] = func(n NotificationContainer) { fn(n.Setting) }
+}
+
+// OnBackgroundProcessingQueue is called when the optimize/sync background queue changes
+func (e *NotificationEvents) OnBackgroundProcessingQueue(fn func(n []BackgroundProcessingQueueEventNotification)) {
+	e.typed[NotificationBackgroundProcessingQueue] = func(n NotificationContainer) { fn(n.BackgroundProcessingQueueEventNotification) }
+}
+
+// OnUpdateStateChange is called when the state of a server update changes
+func (e *NotificationEvents) OnUpdateStateChange(fn func(n []AutoUpdateNotification)) {
+	e.typed[NotificationUpdateStateChange] = func(n NotificationContainer) { fn(n.AutoUpdateNotification) }
+}
+
+// OnTranscodeEnd is called when a transcode finishes
+func (e *NotificationEvents) OnTranscodeEnd(fn func(n []TranscodeSession)) {
+	e.typed[NotificationTranscodeEnd] = func(n NotificationContainer) { fn(n.TranscodeSession) }
+}
+
+// OnTranscodeSessionStart is called when a transcode session starts
+func (e *NotificationEvents) OnTranscodeSessionStart(fn func(n []TranscodeSession)) {
+	e.typed[NotificationTranscodeSessionStart] = func(n NotificationContainer) { fn(n.TranscodeSession) }
+}
+
+// OnTranscodeSessionUpdate is called when a transcode session changes parameters or progresses
+func (e *NotificationEvents) OnTranscodeSessionUpdate(fn func(n []TranscodeSession)) {
+	e.typed[NotificationTranscodeSessionUpdate] = func(n NotificationContainer) { fn(n.TranscodeSession) }
+}
+
+// OnTranscodeSessionEnd is called when a transcode session ends
+func (e *NotificationEvents) OnTranscodeSessionEnd(fn func(n []TranscodeSession)) {
+	e.typed[NotificationTranscodeSessionEnd] = func(n NotificationContainer) { fn(n.TranscodeSession) }
+}
+
+// dispatch decodes a websocket message and passes it to the registered callbacks
+func (e *NotificationEvents) dispatch(message []byte) error {
+	var notif WebsocketNotification
+
+	if err := json.Unmarshal(message, &notif); err != nil {
+		return fmt.Errorf("convert message to json failed: %v", err)
+	}
+
+	if e.raw != nil {
+		e.raw(notif.Type, message)
+	}
+
+	if e.all != nil {
+		e.all(notif.NotificationContainer)
+	}
+
+	if fn, ok := e.events[notif.Type]; ok {
+		fn(notif.NotificationContainer)
+	}
+
+	if fn, ok := e.typed[notif.Type]; ok {
+		fn(notif.NotificationContainer)
+	}
+
+	return nil
+}
+
+// ConnectionState is the state of a notification subscription
+type ConnectionState int
+
+// Connection states reported to SubscribeOptions.OnStateChange
+const (
+	// StateConnecting is reported before every attempt to connect
+	StateConnecting ConnectionState = iota
+	// StateConnected is reported once the websocket is open
+	StateConnected
+	// StateDisconnected is reported when the connection dropped or could not be established. A reconnect follows
+	StateDisconnected
+	// StateClosed is reported when the subscription ended
+	StateClosed
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateClosed:
+		return "closed"
+	}
+
+	return "unknown"
+}
+
+const (
+	defaultReconnectDelay    = time.Second
+	defaultMaxReconnectDelay = time.Minute
+	defaultPingInterval      = 30 * time.Second
+	websocketWriteWait       = 10 * time.Second
+)
+
+// SubscribeOptions configures a notification subscription
+type SubscribeOptions struct {
+	// OnStateChange is called on every connection state transition. err is the reason
+	// of a disconnect or of the subscription ending, if any
+	OnStateChange func(state ConnectionState, err error)
+	// OnError receives errors that do not end the connection, such as malformed notifications
+	OnError func(err error)
+	// ReconnectDelay is the delay before the first reconnect and doubles on each failed attempt. Defaults to 1s
+	ReconnectDelay time.Duration
+	// MaxReconnectDelay caps the delay between reconnects. Defaults to 1m
+	MaxReconnectDelay time.Duration
+	// PingInterval is how often the connection is checked. A connection that does not
+	// answer within two intervals is considered dead. Defaults to 30s
+	PingInterval time.Duration
+	// Dialer used to open the websocket. Defaults to a dialer using the TLS config of the client's transport
+	Dialer *websocket.Dialer
+}
+
+func (o SubscribeOptions) stateChanged(state ConnectionState, err error) {
+	if o.OnStateChange != nil {
+		o.OnStateChange(state, err)
+	}
+}
+
+func (o SubscribeOptions) error(err error) {
+	if o.OnError != nil {
+		o.OnError(err)
+	}
+}
+
+// SubscribeToNotifications connects to your server via websockets listening for events.
+// It reconnects until interrupt receives a signal and passes every error to fn
+//
+// Deprecated: use SubscribeToNotificationsContext which reports connection states
 func (p *Plex) SubscribeToNotifications(events *NotificationEvents, interrupt <-chan os.Signal, fn func(error)) {
-	plexURL, err := url.Parse(p.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// stop waiting for the interrupt once the subscription ends on its own
+	go func() {
+		select {
+		case <-interrupt:
+		case <-ctx.Done():
+		}
+
+		cancel()
+	}()
+
+	go func() {
+		defer cancel()
+
+		err := p.SubscribeToNotificationsContext(ctx, events, SubscribeOptions{
+			OnError: fn,
+			OnStateChange: func(state ConnectionState, err error) {
+				if state == StateDisconnected && err != nil {
+					fn(err)
+				}
+			},
+		})
+
+		if err != nil {
+			fn(err)
+		}
+	}()
+}
+
+// SubscribeToNotificationsContext connects to your server via websockets and passes notifications
+// to events until ctx is done. Dropped connections are reestablished with an exponential backoff.
+// It blocks and returns nil once ctx is done, or an error if the server refuses the token
+func (p *Plex) SubscribeToNotificationsContext(ctx context.Context, events *NotificationEvents, opts SubscribeOptions) error {
+	websocketURL, err := p.notificationsURL()
 
 	if err != nil {
-		fn(err)
-		return
+		return err
 	}
 
-	websocketURL := url.URL{Scheme: "ws", Host: plexURL.Host, Path: "/:/websockets/notifications"}
+	backoff := RetryPolicy{
+		BaseDelay: opts.ReconnectDelay,
+		MaxDelay:  opts.MaxReconnectDelay,
+	}
+
+	if backoff.BaseDelay <= 0 {
+		backoff.BaseDelay = defaultReconnectDelay
+	}
+
+	if backoff.MaxDelay <= 0 {
+		backoff.MaxDelay = defaultMaxReconnectDelay
+	}
+
+	failures := 0
+
+	for {
+		opts.stateChanged(StateConnecting, nil)
+
+		conn, err := p.dialNotifications(ctx, websocketURL, opts.Dialer)
+
+		if err == nil {
+			failures = 0
+
+			opts.stateChanged(StateConnected, nil)
+
+			err = p.readNotifications(ctx, conn, events, opts)
+		}
+
+		if ctx.Err() != nil {
+			opts.stateChanged(StateClosed, nil)
+			return nil
+		}
+
+		// retrying will not fix a bad token
+		if errors.Is(err, ErrUnauthorized) {
+			opts.stateChanged(StateClosed, err)
+			return err
+		}
+
+		failures++
+
+		opts.stateChanged(StateDisconnected, err)
+
+		timer := time.NewTimer(backoff.backoff(failures))
 
-	headers := http.Header{
-		"X-Plex-Token": []string{p.Token},
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			opts.stateChanged(StateClosed, nil)
+			return nil
+		}
 	}
+}
 
-	c, _, err := websocket.DefaultDialer.Dial(websocketURL.String(), headers)
+// notificationsURL returns the websocket endpoint of the server, using wss for https servers
+func (p *Plex) notificationsURL() (string, error) {
+	plexURL, err := url.Parse(p.URL)
 
 	if err != nil {
-		fn(err)
-		return
+		return "", err
 	}
 
-	done := make(chan struct{})
+	scheme := "ws"
 
-	go func() {
-		defer c.Close()
-		defer close(done)
+	if plexURL.Scheme == "https" {
+		scheme = "wss"
+	}
 
-		for {
-			_, message, err := c.ReadMessage()
+	websocketURL := url.URL{
+		Scheme: scheme,
+		Host:   plexURL.Host,
+		Path:   strings.TrimSuffix(plexURL.Path, "/") + "/:/websockets/notifications",
+	}
 
-			if err != nil {
-				fmt.Println("read:", err)
-				fn(err)
-				return
-			}
+	return websocketURL.String(), nil
+}
 
-			// fmt.Printf("\t%s\n", string(message))
+func (p *Plex) dialNotifications(ctx context.Context, websocketURL string, dialer *websocket.Dialer) (*websocket.Conn, error) {
+	if dialer == nil {
+		dialer = &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: 45 * time.Second,
+		}
 
-			var notif WebsocketNotification
+		if transport, ok := p.HTTPClient.Transport.(*http.Transport); ok {
+			dialer.TLSClientConfig = transport.TLSClientConfig
+		}
+	}
 
-			if err := json.Unmarshal(message, &notif); err != nil {
-				fmt.Printf("convert message to json failed: %v\n", err)
-				continue
-			}
+	// reuse the plex headers of regular requests for the handshake
+	req, err := p.newRequest(ctx, http.MethodGet, websocketURL, nil, p.Headers)
 
-			// fmt.Println(notif.Type)
-			fn, ok := events.events[notif.Type]
+	if err != nil {
+		return nil, err
+	}
 
-			if !ok {
-				fmt.Printf("unknown websocket event name: %v\n", notif.Type)
-				continue
-			}
+	conn, resp, err := dialer.DialContext(ctx, websocketURL, req.Header)
 
-			fn(notif.NotificationContainer)
+	if err != nil && resp != nil {
+		return nil, newAPIError(resp)
+	}
+
+	if err != nil {
+		return nil, &RequestError{
+			Method:   http.MethodGet,
+			Endpoint: endpointOf(req),
+			Err:      err,
 		}
-	}()
+	}
+
+	return conn, nil
+}
+
+// readNotifications dispatches notifications until the connection drops or ctx is done
+func (p *Plex) readNotifications(ctx context.Context, conn *websocket.Conn, events *NotificationEvents, opts SubscribeOptions) error {
+	defer conn.Close()
+
+	pingInterval := opts.PingInterval
+
+	if pingInterval <= 0 {
+		pingInterval = defaultPingInterval
+	}
+
+	pongWait := 2 * pingInterval
+
+	done := make(chan struct{})
+	defer close(done)
 
 	go func() {
-		ticker := time.NewTicker(time.Second)
+		ticker := time.NewTicker(pingInterval)
 		defer ticker.Stop()
 
 		for {
 			select {
-			case t := <-ticker.C:
-				err := c.WriteMessage(websocket.TextMessage, []byte(t.String()))
-
-				if err != nil {
-					fn(err)
-				}
-			case <-interrupt:
-				fmt.Println("interrupt")
+			case <-done:
+				return
+			case <-ctx.Done():
 				// To cleanly close a connection, a client should send a close
 				// frame and wait for the server to close the connection.
-				err := c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-
-				if err != nil {
-					fmt.Println("write close:", err)
-					fn(err)
-				}
-
-				select {
-				case <-done:
-				case <-time.After(time.Second):
-					fmt.Println("closing websocket...")
-					c.Close()
-				}
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(websocketWriteWait))
+				conn.SetReadDeadline(time.Now().Add(time.Second))
 				return
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(websocketWriteWait)); err != nil {
+					conn.Close()
+					return
+				}
 			}
 		}
 	}()
+
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, message, err := conn.ReadMessage()
+
+		if err != nil {
+			return err
+		}
+
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		if err := events.dispatch(message); err != nil {
+			opts.error(err)
+		}
+	}
 }
@@ -0,0 +1,209 @@
+package plex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const testPlayingNotification = `{"NotificationContainer":{"type":"playing","size":1,"PlaySessionStateNotification":[{"sessionKey":"12","ratingKey":"10","state":"playing","viewOffset":1000}]}}`
+
+func TestNotificationsURL(t *testing.T) {
+	tests := map[string]string{
+		"http://192.168.1.2:32400":               "ws://192.168.1.2:32400/:/websockets/notifications",
+		"https://plex.example.com/":              "wss://plex.example.com/:/websockets/notifications",
+		"https://example.com/plex/":              "wss://example.com/plex/:/websockets/notifications",
+		"https://10-0-0-1.abc.plex.direct:32400": "wss://10-0-0-1.abc.plex.direct:32400/:/websockets/notifications",
+	}
+
+	for serverURL, expected := range tests {
+		p := &Plex{URL: serverURL}
+
+		websocketURL, err := p.notificationsURL()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if websocketURL != expected {
+			t.Errorf("expected %s, got %s", expected, websocketURL)
+		}
+	}
+}
+
+func TestSubscribeToNotificationsReconnects(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	connections := 0
+
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Plex-Token") != "abc123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		mu.Lock()
+		connections++
+		mu.Unlock()
+
+		conn.WriteMessage(websocket.TextMessage, []byte(testPlayingNotification))
+
+		// drop the connection to force a reconnect
+		conn.Close()
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	notifications := 0
+	events := NewNotificationEvents()
+
+	events.OnPlaying(func(n NotificationContainer) {
+		if n.PlaySessionStateNotification[0].SessionKey != "12" {
+			t.Errorf("unexpected notification: %+v", n)
+		}
+
+		notifications++
+
+		if notifications == 2 {
+			cancel()
+		}
+	})
+
+	var states []ConnectionState
+
+	err = p.SubscribeToNotificationsContext(ctx, events, SubscribeOptions{
+		ReconnectDelay: time.Millisecond,
+		OnStateChange: func(state ConnectionState, err error) {
+			states = append(states, state)
+		},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if notifications != 2 {
+		t.Fatalf("expected a notification from each connection, got %d", notifications)
+	}
+
+	if states[0] != StateConnecting || states[1] != StateConnected || states[len(states)-1] != StateClosed {
+		t.Errorf("unexpected state transitions: %v", states)
+	}
+
+	foundDisconnect := false
+
+	for _, state := range states {
+		if state == StateDisconnected {
+			foundDisconnect = true
+		}
+	}
+
+	if !foundDisconnect {
+		t.Errorf("expected a disconnect before reconnecting: %v", states)
+	}
+}
+
+func TestSubscribeToNotificationsUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	p, err := New(server.URL, "wrong")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = p.SubscribeToNotificationsContext(ctx, NewNotificationEvents(), SubscribeOptions{ReconnectDelay: time.Millisecond})
+
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestNotificationEventsDispatch(t *testing.T) {
+	events := NewNotificationEvents()
+
+	var (
+		playing    bool
+		sessions   []PlaySessionStateNotification
+		activities []ActivityNotification
+		timeline   []TimelineEntry
+		transcodes []TranscodeSession
+		rawTypes   []string
+	)
+
+	events.OnPlaying(func(n NotificationContainer) { playing = true })
+	events.OnPlaySessionState(func(n []PlaySessionStateNotification) { sessions = n })
+	events.OnActivity(func(n []ActivityNotification) { activities = n })
+	events.OnTimeline(func(n []TimelineEntry) { timeline = n })
+	events.OnTranscodeSessionEnd(func(n []TranscodeSession) { transcodes = n })
+	events.OnRaw(func(notificationType string, message []byte) { rawTypes = append(rawTypes, notificationType) })
+
+	messages := []string{
+		testPlayingNotification,
+		`{"NotificationContainer":{"type":"activity","size":1,"ActivityNotification":[{"event":"started","uuid":"abc","Activity":{"type":"library.update.section","title":"Scanning Movies","progress":0}}]}}`,
+		`{"NotificationContainer":{"type":"timeline","size":1,"TimelineEntry":[{"identifier":"com.plexapp.plugins.library","sectionID":1,"itemID":10,"type":1,"title":"Heat","state":5}]}}`,
+		`{"NotificationContainer":{"type":"transcodeSession.end","size":1,"TranscodeSession":[{"key":"/transcode/sessions/xyz","videoDecision":"transcode"}]}}`,
+		`{"NotificationContainer":{"type":"account","size":0}}`,
+	}
+
+	for _, message := range messages {
+		if err := events.dispatch([]byte(message)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !playing || len(sessions) != 1 || sessions[0].ViewOffset != 1000 {
+		t.Errorf("expected both playing handlers to be called, got %v %+v", playing, sessions)
+	}
+
+	if len(activities) != 1 || activities[0].Activity.Title != "Scanning Movies" {
+		t.Errorf("unexpected activities: %+v", activities)
+	}
+
+	if len(timeline) != 1 || timeline[0].ItemID != 10 {
+		t.Errorf("unexpected timeline: %+v", timeline)
+	}
+
+	if len(transcodes) != 1 || transcodes[0].VideoDecision != "transcode" {
+		t.Errorf("unexpected transcode sessions: %+v", transcodes)
+	}
+
+	expectedTypes := []string{"playing", "activity", "timeline", "transcodeSession.end", "account"}
+
+	if fmt.Sprint(rawTypes) != fmt.Sprint(expectedTypes) {
+		t.Errorf("expected raw handler to see %v, got %v", expectedTypes, rawTypes)
+	}
+
+	if err := events.dispatch([]byte("not json")); err == nil {
+		t.Error("expected malformed notification to fail")
+	}
+}